@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -72,7 +74,89 @@ func saveToken(path string, token *oauth2.Token) {
 	json.NewEncoder(f).Encode(token)
 }
 
+// memoryLog is a reject.DecisionLog that lasts as long as the process.
+type memoryLog struct {
+	nextId    int
+	decisions map[string]*reject.Decision
+}
+
+func (l *memoryLog) Record(ctx context.Context, d *reject.Decision) error {
+	l.nextId++
+	d.Id = strconv.Itoa(l.nextId)
+	if d.Standing() {
+		l.decisions[d.Id] = d
+	}
+	return nil
+}
+
+func (l *memoryLog) ByBlocker(ctx context.Context, calId, blockerId string) (
+	rv []*reject.Decision, err error) {
+	for _, d := range l.decisions {
+		if d.CalId == calId && d.BlockerId == blockerId {
+			rv = append(rv, d)
+		}
+	}
+	return rv, nil
+}
+
+func (l *memoryLog) Resolve(ctx context.Context, d *reject.Decision) error {
+	delete(l.decisions, d.Id)
+	return nil
+}
+
+var (
+	rulesFile = flag.String("rules", "",
+		"path to a JSON rule set, in the same format as the web app's blocker rules")
+	allowlist = flag.String("allow", "",
+		"organizer addresses or domains whose invites are never responded to")
+	denylist = flag.String("deny", "",
+		"organizer addresses or domains whose invites are always declined")
+	replyTemplate = flag.String("reply", reject.DefaultReply,
+		"reply template for declined invites")
+	suggestSlots = flag.Int("suggest", 3,
+		"how many free slots to suggest in decline comments")
+	workingHours = flag.String("working-hours", reject.DefaultWorkingHours,
+		"working hours for suggested slots")
+	outsideHours = flag.Bool("outside-hours", false,
+		"decline invites that are not entirely within working hours")
+	dailyLoad = flag.Duration("daily-load", 0,
+		"decline invites that would put more meetings than this on a day")
+	weeklyLoad = flag.Duration("weekly-load", 0,
+		"decline invites that would put more meetings than this on a week")
+	backToBack = flag.Duration("back-to-back", 0,
+		"decline invites that would make a longer run of back-to-back meetings")
+	backToBackGap = flag.Duration("back-to-back-gap", 5*time.Minute,
+		"gaps shorter than this don't break a run of back-to-back meetings")
+	minLead = flag.Duration("short-notice", 0,
+		"decline invites sent less than this long before they start")
+	maxLead = flag.Duration("far-future", 0,
+		"decline invites sent more than this long before they start")
+	requireAgenda = flag.Bool("require-agenda", false,
+		"decline invites with an empty description")
+	maxDuration = flag.Duration("max-duration", 0,
+		"decline invites longer than this")
+	requireConference = flag.Bool("require-conference", false,
+		"decline invites with neither a video call nor a location")
+	maxAttendees = flag.Int("max-attendees", 0,
+		"mark invites with more attendees than this tentative")
+	internalDomains = flag.String("internal", "",
+		"internal domains for rules with external_only, instead of your own")
+	silentOptional = flag.Bool("silent-optional", false,
+		"decline invites you're optional on without a reply or notification")
+	keywords = flag.String("keywords", "",
+		"comma separated title or description keywords whose invites are always declined")
+	nativeTypes = flag.String("native", "",
+		"comma separated event types that are blockers: outOfOffice, focusTime")
+	workingLocation = flag.String("working-location", "",
+		"working location days that are blockers: notInOffice, homeOffice or customLocation")
+	floating = flag.String("floating", "",
+		"floating blocks to keep free, like \"mon-fri 11:30-14:00 45m\"")
+	dryRun = flag.Bool("dry-run", false,
+		"report what would be done with the next four weeks of pending invites and exit without changing anything")
+)
+
 func main() {
+	flag.Parse()
 	ctx := context.Background()
 	b, err := ioutil.ReadFile("credentials.json")
 	if err != nil {
@@ -91,23 +175,150 @@ func main() {
 		log.Fatalf("Unable to retrieve Calendar client: %v", err)
 	}
 
-	autorejectSummary := "(autoreject)"
-	autorejectComment := "Automatic decline - unavailable. Please consider scheduling this during free time at a later date."
+	rules := reject.DefaultRuleSet("(autoreject)")
+	if *rulesFile != "" {
+		data, err := ioutil.ReadFile(*rulesFile)
+		if err != nil {
+			log.Fatalf("Unable to read rules file: %v", err)
+		}
+		rules, err = reject.ParseRuleSet(data)
+		if err != nil {
+			log.Fatalf("Unable to parse rules file: %v", err)
+		}
+	}
+	if *nativeTypes != "" {
+		rules.EventTypes = append(rules.EventTypes,
+			strings.Split(*nativeTypes, ",")...)
+	}
+	if *workingLocation != "" {
+		rules.WorkingLocations = append(rules.WorkingLocations, *workingLocation)
+	}
+	err = rules.Validate()
+	if err != nil {
+		log.Fatalf("Unable to use native blockers: %v", err)
+	}
+	reply, err := reject.ParseReply(*replyTemplate)
+	if err != nil {
+		log.Fatalf("Unable to parse reply: %v", err)
+	}
+	allow, err := reject.ParseAddressList(*allowlist)
+	if err != nil {
+		log.Fatalf("Unable to parse allowlist: %v", err)
+	}
+	deny, err := reject.ParseAddressList(*denylist)
+	if err != nil {
+		log.Fatalf("Unable to parse denylist: %v", err)
+	}
+	hours, err := reject.ParseSchedule(*workingHours)
+	if err != nil {
+		log.Fatalf("Unable to parse working hours: %v", err)
+	}
+	internal, err := reject.ParseAddressList(*internalDomains)
+	if err != nil {
+		log.Fatalf("Unable to parse internal domains: %v", err)
+	}
+	keywordList, err := reject.ParseKeywordList(
+		strings.ReplaceAll(*keywords, ",", "\n"))
+	if err != nil {
+		log.Fatalf("Unable to parse keywords: %v", err)
+	}
+	floatingBlocks, err := reject.ParseFloatingBlocks(*floating)
+	if err != nil {
+		log.Fatalf("Unable to parse floating blocks: %v", err)
+	}
+	opts := reject.Options{
+		Rules:        rules,
+		SuggestSlots: *suggestSlots,
+		WorkingHours: hours,
+		Reply:        reply,
+		Allow:        allow,
+		Deny:         deny,
+		OutsideHours: reject.Policy{
+			Enabled: *outsideHours,
+			Reply:   reject.MustParseReply(reject.DefaultWorkingHoursReply),
+		},
+		DailyLoad:  *dailyLoad,
+		WeeklyLoad: *weeklyLoad,
+		OverLoad: reject.Policy{
+			Enabled: *dailyLoad > 0 || *weeklyLoad > 0,
+			Reply:   reject.MustParseReply(reject.DefaultLoadReply),
+		},
+		BackToBackLimit: *backToBack,
+		BackToBackGap:   *backToBackGap,
+		BackToBack: reject.Policy{
+			Enabled: *backToBack > 0,
+			Reply:   reject.MustParseReply(reject.DefaultBackToBackReply),
+		},
+		MinLeadTime: *minLead,
+		ShortNotice: reject.Policy{
+			Enabled: *minLead > 0,
+			Reply:   reject.MustParseReply(reject.DefaultShortNoticeReply),
+		},
+		MaxLeadTime: *maxLead,
+		FarFuture: reject.Policy{
+			Enabled: *maxLead > 0,
+			Reply:   reject.MustParseReply(reject.DefaultFarFutureReply),
+		},
+		NoAgenda: reject.Policy{
+			Enabled: *requireAgenda,
+			Reply:   reject.MustParseReply(reject.DefaultNoAgendaReply),
+		},
+		MaxDuration: *maxDuration,
+		TooLong: reject.Policy{
+			Enabled: *maxDuration > 0,
+			Reply:   reject.MustParseReply(reject.DefaultTooLongReply),
+		},
+		NoConference: reject.Policy{
+			Enabled: *requireConference,
+			Reply:   reject.MustParseReply(reject.DefaultNoConferenceReply),
+		},
+		MaxAttendees: *maxAttendees,
+		LargeMeeting: reject.Policy{
+			Enabled:  *maxAttendees > 0,
+			Response: reject.Response{Action: reject.ActionTentative},
+			Reply:    reject.MustParseReply(reject.DefaultLargeMeetingReply),
+		},
+		InternalDomains: internal,
+		Keywords:        keywordList,
+		KeywordDeny: reject.Policy{
+			Enabled: len(keywordList) > 0,
+			Reply:   reject.MustParseReply(reject.DefaultKeywordReply),
+		},
+		Optional: reject.Policy{
+			Enabled:  *silentOptional,
+			Response: reject.Response{Notify: "none"},
+			Reply:    reject.MustParseReply(""),
+		},
+		FloatingBlocks: floatingBlocks,
+		Floating: reject.Policy{
+			Enabled: len(floatingBlocks) > 0,
+			Reply:   reject.MustParseReply(reject.DefaultFloatingReply),
+		},
+		Log:            &memoryLog{decisions: map[string]*reject.Decision{}},
+		OldestCreation: time.Now(),
+	}
+
+	if *dryRun {
+		// report on every upcoming pending invite, not just ones that arrive
+		// from now on
+		opts.OldestCreation = time.Time{}
+		opts.DryRun = true
+		opts.Upcoming = reject.PreviewHorizon
+		decisions, err := reject.RejectBadInvites(ctx, srv, "primary", "", opts, nil)
+		if err != nil {
+			panic(err)
+		}
+		for _, d := range decisions {
+			fmt.Printf("%s\t%s\t%q\t%s\t%s\t%s\n", d.Action, d.InviteId,
+				d.Summary, d.Organizer, d.Start.Format(time.RFC3339), d.Reason())
+		}
+		return
+	}
 
 	lastSyncToken := ""
 
-	startTime := time.Now()
-
 	for {
-		err = reject.RejectBadInvites(ctx, srv, "primary", lastSyncToken, func(e *calendar.Event) bool {
-			if !strings.Contains(strings.ToLower(e.Summary), autorejectSummary) {
-				return false
-			}
-			if len(e.Attendees) != 0 {
-				return false
-			}
-			return true
-		}, autorejectComment, startTime, func(ctx context.Context, nextSyncToken string) error {
+		_, err = reject.RejectBadInvites(ctx, srv, "primary", lastSyncToken, opts, func(ctx context.Context, nextSyncToken string) error {
 			fmt.Printf("next sync token %q\n", nextSyncToken)
 			lastSyncToken = nextSyncToken
 			return nil
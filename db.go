@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/jtolio/autoreject/reject"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/iterator"
 )
@@ -24,8 +27,33 @@ type DSChannel struct {
 }
 
 var DefaultConfigValues = map[string]string{
-	"autoreject_name":  "(autoreject)",
-	"autoreject_reply": "Automatic decline - unavailable. Please consider scheduling this during free time at a later date.",
+	"autoreject_name":      "(autoreject)",
+	"autoreject_reply":     reject.DefaultReply,
+	"suggest_slots":        "3",
+	"working_hours":        reject.DefaultWorkingHours,
+	"working_hours_reply":  reject.DefaultWorkingHoursReply,
+	"load_reply":           reject.DefaultLoadReply,
+	"floating_reply":       reject.DefaultFloatingReply,
+	"backtoback_gap":       "5",
+	"backtoback_reply":     reject.DefaultBackToBackReply,
+	"short_notice_action":  string(reject.ActionDecline),
+	"short_notice_reply":   reject.DefaultShortNoticeReply,
+	"far_future_action":    string(reject.ActionDecline),
+	"far_future_reply":     reject.DefaultFarFutureReply,
+	"agenda_action":        string(reject.ActionDecline),
+	"agenda_reply":         reject.DefaultNoAgendaReply,
+	"duration_action":      string(reject.ActionDecline),
+	"duration_reply":       reject.DefaultTooLongReply,
+	"conference_action":    string(reject.ActionDecline),
+	"conference_reply":     reject.DefaultNoConferenceReply,
+	"large_meeting_action": string(reject.ActionTentative),
+	"large_meeting_reply":  reject.DefaultLargeMeetingReply,
+	"optional_action":      string(reject.ActionDecline),
+	"optional_notify":      "none",
+	"keyword_reply":        reject.DefaultKeywordReply,
+	"restore_status":       "needsAction",
+	"moved_accepted":       string(reject.MovedKeep),
+	"restore_reply":        "My earlier automatic decline is withdrawn, the time is free again.",
 }
 
 type DSConfigString struct {
@@ -33,7 +61,56 @@ type DSConfigString struct {
 	Value string `datastore:",noindex"`
 	// Settings:
 	// * autoreject_name
-	// * autoreject_reply
+	// * autoreject_reply (text/template, see reject.ReplyData)
+	// * autoreject_rules (JSON encoded reject.RuleSet, overrides
+	//   autoreject_name when set)
+	// * native_outofoffice
+	// * native_focustime
+	// * native_working_location (see reject.Rule.WorkingLocation)
+	// * suggest_slots
+	// * working_hours (see reject.ParseSchedule)
+	// * working_hours_decline
+	// * working_hours_reply (text/template, see reject.ReplyData)
+	// * load_daily_hours (empty or 0 for no cap)
+	// * load_weekly_hours (empty or 0 for no cap)
+	// * load_reply (text/template, see reject.ReplyData)
+	// * floating_blocks (see reject.ParseFloatingBlocks)
+	// * floating_reply (text/template, see reject.ReplyData)
+	// * backtoback_hours (empty or 0 for no limit)
+	// * backtoback_gap (minutes)
+	// * backtoback_reply (text/template, see reject.ReplyData)
+	// * short_notice_hours (empty or 0 for no minimum)
+	// * short_notice_action (see reject.ParseAction)
+	// * short_notice_reply (text/template, see reject.ReplyData)
+	// * far_future_days (empty or 0 for no maximum)
+	// * far_future_action (see reject.ParseAction)
+	// * far_future_reply (text/template, see reject.ReplyData)
+	// * agenda_required
+	// * agenda_action (see reject.ParseAction)
+	// * agenda_reply (text/template, see reject.ReplyData)
+	// * duration_max_minutes (empty or 0 for no maximum)
+	// * duration_action (see reject.ParseAction)
+	// * duration_reply (text/template, see reject.ReplyData)
+	// * conference_required
+	// * conference_action (see reject.ParseAction)
+	// * conference_reply (text/template, see reject.ReplyData)
+	// * large_meeting_attendees (empty or 0 for no maximum)
+	// * large_meeting_action (see reject.ParseAction)
+	// * large_meeting_reply (text/template, see reject.ReplyData)
+	// * internal_domains (see reject.ParseAddressList)
+	// * optional_separate
+	// * optional_action (see reject.ParseAction)
+	// * optional_notify (see reject.ParseNotify)
+	// * optional_reply (text/template, see reject.ReplyData)
+	// * keyword_denylist (see reject.ParseKeywordList)
+	// * keyword_reply (text/template, see reject.ReplyData)
+	// * allowlist (see reject.ParseAddressList)
+	// * denylist (see reject.ParseAddressList)
+	// * restore_status (needsAction or accepted)
+	// * restore_notify
+	// * restore_reply (text/template, see reject.ReplyData)
+	// * allowlist_temp (lines of "address expiry", see tempAllowlist)
+	// * moved_accepted (see reject.MovedPolicy)
 	// * syncstart-<calid>
 	// * synctoken-<calid>
 }
@@ -45,6 +122,36 @@ type DSConfigBytes struct {
 	// * oauth2_token
 }
 
+type DSDecision struct {
+	// Datastore Key should be IDKey("Decision", id, userKey)
+	Time      time.Time
+	CalId     string
+	InviteId  string
+	Summary   string `datastore:",noindex"`
+	Organizer string
+	Start     time.Time `datastore:",noindex"`
+	End       time.Time `datastore:",noindex"`
+	BlockerId string
+	Blocker   string `datastore:",noindex"`
+	Rule      string `datastore:",noindex"`
+	ListEntry string `datastore:",noindex"`
+	Policy    string `datastore:",noindex"`
+	Keyword   string `datastore:",noindex"`
+	Action    string
+	Series    bool `datastore:",noindex"`
+	Optional  bool `datastore:",noindex"`
+	// Resolved is set once the decision no longer stands.
+	Resolved bool
+	Undone   bool
+}
+
+type DSEventTimes struct {
+	// Datastore Key should be NameKey("EventTimes", calId+"/"+eventId, userKey)
+	Start   time.Time `datastore:",noindex"`
+	End     time.Time `datastore:",noindex"`
+	Changed time.Time `datastore:",noindex"`
+}
+
 type DB struct {
 	datastore *datastore.Client
 }
@@ -69,6 +176,10 @@ func (d *DB) configStringKey(userId, name string) *datastore.Key {
 	return datastore.NameKey("ConfigString", name, d.userKey(userId))
 }
 
+func (d *DB) decisionKey(userId string, id int64) *datastore.Key {
+	return datastore.IDKey("Decision", id, d.userKey(userId))
+}
+
 func (d *DB) channelKey(channelId string) *datastore.Key {
 	return datastore.NameKey("Channel", channelId, nil)
 }
@@ -157,6 +268,37 @@ func (d *DB) GetStringSetting(ctx context.Context, userId, name string) (string,
 	return val.Value, nil
 }
 
+// GetStringSettings is GetStringSetting for many settings at once.
+func (d *DB) GetStringSettings(ctx context.Context, userId string,
+	names ...string) (map[string]string, error) {
+	keys := make([]*datastore.Key, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, d.configStringKey(userId, name))
+	}
+	vals := make([]DSConfigString, len(names))
+	err := d.datastore.GetMulti(ctx, keys, vals)
+	if err != nil {
+		multi, ok := err.(datastore.MultiError)
+		if !ok {
+			return nil, Err.Wrap(err)
+		}
+		for i, err := range multi {
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, datastore.ErrNoSuchEntity) {
+				return nil, Err.Wrap(err)
+			}
+			vals[i].Value = DefaultConfigValues[names[i]]
+		}
+	}
+	rv := make(map[string]string, len(names))
+	for i, name := range names {
+		rv[name] = vals[i].Value
+	}
+	return rv, nil
+}
+
 func (d *DB) SetStringSetting(ctx context.Context, userId, name, value string) error {
 	_, err := d.datastore.Put(ctx, d.configStringKey(userId, name), &DSConfigString{Value: value})
 	return Err.Wrap(err)
@@ -180,3 +322,270 @@ func (d *DB) AllChannels(ctx context.Context,
 		}
 	}
 }
+
+func decisionFromDS(key *datastore.Key, val *DSDecision) *reject.Decision {
+	return &reject.Decision{
+		Id:        strconv.FormatInt(key.ID, 10),
+		Time:      val.Time,
+		CalId:     val.CalId,
+		InviteId:  val.InviteId,
+		Summary:   val.Summary,
+		Organizer: val.Organizer,
+		Start:     val.Start,
+		End:       val.End,
+		BlockerId: val.BlockerId,
+		Blocker:   val.Blocker,
+		Rule:      val.Rule,
+		ListEntry: val.ListEntry,
+		Policy:    val.Policy,
+		Keyword:   val.Keyword,
+		Action:    reject.Action(val.Action),
+		Series:    val.Series,
+		Optional:  val.Optional,
+		Undone:    val.Undone,
+		Resolved:  val.Resolved,
+	}
+}
+
+func (d *DB) RecordDecision(ctx context.Context, userId string,
+	decision *reject.Decision) error {
+	key, err := d.datastore.Put(ctx,
+		datastore.IncompleteKey("Decision", d.userKey(userId)), &DSDecision{
+			Time:      decision.Time,
+			CalId:     decision.CalId,
+			InviteId:  decision.InviteId,
+			Summary:   decision.Summary,
+			Organizer: strings.ToLower(decision.Organizer),
+			Start:     decision.Start,
+			End:       decision.End,
+			BlockerId: decision.BlockerId,
+			Blocker:   decision.Blocker,
+			Rule:      decision.Rule,
+			ListEntry: decision.ListEntry,
+			Policy:    decision.Policy,
+			Keyword:   decision.Keyword,
+			Action:    string(decision.Action),
+			Series:    decision.Series,
+			Optional:  decision.Optional,
+			Resolved:  !decision.Standing(),
+		})
+	if err != nil {
+		return Err.Wrap(err)
+	}
+	decision.Id = strconv.FormatInt(key.ID, 10)
+	return nil
+}
+
+func (d *DB) DecisionsByBlocker(ctx context.Context, userId, calId,
+	blockerId string) (decisions []*reject.Decision, err error) {
+	it := d.datastore.Run(ctx, datastore.NewQuery("Decision").
+		Ancestor(d.userKey(userId)).
+		Filter("CalId =", calId).
+		Filter("BlockerId =", blockerId).
+		Filter("Resolved =", false))
+	for {
+		var val DSDecision
+		key, err := it.Next(&val)
+		if errors.Is(err, iterator.Done) {
+			return decisions, nil
+		}
+		if err != nil {
+			return decisions, Err.Wrap(err)
+		}
+		decisions = append(decisions, decisionFromDS(key, &val))
+	}
+}
+
+// DecisionFilter narrows down Decisions. Zero fields don't filter.
+type DecisionFilter struct {
+	From, To  time.Time
+	Organizer string
+}
+
+// Decisions returns a page of a user's decisions, newest first, and the
+// cursor for the next page, which is empty on the last page.
+func (d *DB) Decisions(ctx context.Context, userId string,
+	filter DecisionFilter, cursor string, limit int) (
+	decisions []*reject.Decision, next string, err error) {
+	q := datastore.NewQuery("Decision").Ancestor(d.userKey(userId))
+	if !filter.From.IsZero() {
+		q = q.Filter("Time >=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Filter("Time <", filter.To)
+	}
+	if filter.Organizer != "" {
+		q = q.Filter("Organizer =", filter.Organizer)
+	}
+	q = q.Order("-Time").Limit(limit)
+	if cursor != "" {
+		c, err := datastore.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", Err.Wrap(err)
+		}
+		q = q.Start(c)
+	}
+
+	it := d.datastore.Run(ctx, q)
+	for {
+		var val DSDecision
+		key, err := it.Next(&val)
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, "", Err.Wrap(err)
+		}
+		decisions = append(decisions, decisionFromDS(key, &val))
+	}
+	if len(decisions) < limit {
+		return decisions, "", nil
+	}
+	c, err := it.Cursor()
+	if err != nil {
+		return nil, "", Err.Wrap(err)
+	}
+	return decisions, c.String(), nil
+}
+
+func (d *DB) eventTimesKey(userId, calId, eventId string) *datastore.Key {
+	return datastore.NameKey("EventTimes", calId+"/"+eventId, d.userKey(userId))
+}
+
+// eventTimesBatch is how many EventTimes are read or written per call, to
+// stay under the Datastore limits.
+const eventTimesBatch = 500
+
+func (d *DB) GetEventTimes(ctx context.Context, userId, calId string,
+	eventIds []string) (map[string]reject.EventTimes, error) {
+	rv := make(map[string]reject.EventTimes, len(eventIds))
+	for len(eventIds) > 0 {
+		batch := eventIds
+		if len(batch) > eventTimesBatch {
+			batch = batch[:eventTimesBatch]
+		}
+		eventIds = eventIds[len(batch):]
+
+		keys := make([]*datastore.Key, 0, len(batch))
+		for _, eventId := range batch {
+			keys = append(keys, d.eventTimesKey(userId, calId, eventId))
+		}
+		vals := make([]DSEventTimes, len(batch))
+		err := d.datastore.GetMulti(ctx, keys, vals)
+		var multi datastore.MultiError
+		if err != nil && !errors.As(err, &multi) {
+			return nil, Err.Wrap(err)
+		}
+		for i, eventId := range batch {
+			if multi != nil && multi[i] != nil {
+				if errors.Is(multi[i], datastore.ErrNoSuchEntity) {
+					continue
+				}
+				return nil, Err.Wrap(multi[i])
+			}
+			rv[eventId] = reject.EventTimes{
+				Start:   vals[i].Start,
+				End:     vals[i].End,
+				Changed: vals[i].Changed,
+			}
+		}
+	}
+	return rv, nil
+}
+
+func (d *DB) PutEventTimes(ctx context.Context, userId, calId string,
+	times map[string]reject.EventTimes) error {
+	keys := make([]*datastore.Key, 0, eventTimesBatch)
+	vals := make([]*DSEventTimes, 0, eventTimesBatch)
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		_, err := d.datastore.PutMulti(ctx, keys, vals)
+		keys, vals = keys[:0], vals[:0]
+		return Err.Wrap(err)
+	}
+	for eventId, t := range times {
+		keys = append(keys, d.eventTimesKey(userId, calId, eventId))
+		vals = append(vals, &DSEventTimes{
+			Start: t.Start, End: t.End, Changed: t.Changed})
+		if len(keys) >= eventTimesBatch {
+			err := flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+func (d *DB) DeleteEventTimes(ctx context.Context, userId, calId string,
+	eventIds []string) error {
+	for len(eventIds) > 0 {
+		batch := eventIds
+		if len(batch) > eventTimesBatch {
+			batch = batch[:eventTimesBatch]
+		}
+		eventIds = eventIds[len(batch):]
+
+		keys := make([]*datastore.Key, 0, len(batch))
+		for _, eventId := range batch {
+			keys = append(keys, d.eventTimesKey(userId, calId, eventId))
+		}
+		err := d.datastore.DeleteMulti(ctx, keys)
+		if err != nil {
+			return Err.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (d *DB) GetDecision(ctx context.Context, userId, decisionId string) (
+	*reject.Decision, error) {
+	id, err := strconv.ParseInt(decisionId, 10, 64)
+	if err != nil {
+		return nil, Err.Wrap(err)
+	}
+	key := d.decisionKey(userId, id)
+	var val DSDecision
+	err = d.datastore.Get(ctx, key, &val)
+	if err != nil {
+		return nil, Err.Wrap(err)
+	}
+	return decisionFromDS(key, &val), nil
+}
+
+func (d *DB) updateDecision(ctx context.Context, userId, decisionId string,
+	update func(*DSDecision)) error {
+	id, err := strconv.ParseInt(decisionId, 10, 64)
+	if err != nil {
+		return Err.Wrap(err)
+	}
+	key := d.decisionKey(userId, id)
+	_, err = d.datastore.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var val DSDecision
+		err := tx.Get(key, &val)
+		if err != nil {
+			return err
+		}
+		update(&val)
+		_, err = tx.Put(key, &val)
+		return err
+	})
+	return Err.Wrap(err)
+}
+
+func (d *DB) ResolveDecision(ctx context.Context, userId, decisionId string) error {
+	return d.updateDecision(ctx, userId, decisionId, func(val *DSDecision) {
+		val.Resolved = true
+	})
+}
+
+// UndoDecision marks a decision as undone by the user, which also resolves
+// it.
+func (d *DB) UndoDecision(ctx context.Context, userId, decisionId string) error {
+	return d.updateDecision(ctx, userId, decisionId, func(val *DSDecision) {
+		val.Resolved = true
+		val.Undone = true
+	})
+}
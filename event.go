@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,34 +13,318 @@ import (
 	"gopkg.in/webhelp.v1/whfatal"
 )
 
-func (s *Site) sync(ctx context.Context, chanId string, channel *DSChannel) error {
-	syncToken, err := s.db.GetStringSetting(ctx, channel.UserId,
-		"synctoken-"+channel.CalId)
+func rules(settings map[string]string) (rs *reject.RuleSet, err error) {
+	rs = reject.DefaultRuleSet(settings["autoreject_name"])
+	if rulesJSON := settings["autoreject_rules"]; strings.TrimSpace(rulesJSON) != "" {
+		rs, err = reject.ParseRuleSet([]byte(rulesJSON))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if settings["native_outofoffice"] != "" {
+		rs.EventTypes = append(rs.EventTypes, "outOfOffice")
+	}
+	if settings["native_focustime"] != "" {
+		rs.EventTypes = append(rs.EventTypes, "focusTime")
+	}
+	if where := settings["native_working_location"]; where != "" {
+		rs.WorkingLocations = append(rs.WorkingLocations, where)
+	}
+	return rs, nil
+}
+
+// decisionLog keeps a user's decisions in the DB.
+type decisionLog struct {
+	db     *DB
+	userId string
+}
+
+func (l *decisionLog) Record(ctx context.Context, d *reject.Decision) error {
+	return l.db.RecordDecision(ctx, l.userId, d)
+}
+
+func (l *decisionLog) ByBlocker(ctx context.Context, calId, blockerId string) (
+	[]*reject.Decision, error) {
+	return l.db.DecisionsByBlocker(ctx, l.userId, calId, blockerId)
+}
+
+func (l *decisionLog) Resolve(ctx context.Context, d *reject.Decision) error {
+	return l.db.ResolveDecision(ctx, l.userId, d.Id)
+}
+
+// parseHours parses a number of hours, where empty means zero.
+func parseHours(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+	hours, err := strconv.ParseFloat(val, 64)
 	if err != nil {
-		return err
+		return 0, Err.Wrap(err)
+	}
+	if hours < 0 {
+		return 0, Err.New("negative number of hours")
 	}
+	return time.Duration(hours * float64(time.Hour)), nil
+}
 
-	autorejectName, err := s.db.GetStringSetting(ctx, channel.UserId,
-		"autoreject_name")
+// parseCount parses a count, where empty means zero.
+func parseCount(val string) (int, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(val)
 	if err != nil {
-		return err
+		return 0, Err.Wrap(err)
 	}
-	autorejectName = strings.ToLower(strings.TrimSpace(autorejectName))
+	if n < 0 {
+		return 0, Err.New("negative count")
+	}
+	return n, nil
+}
 
-	autorejectReply, err := s.db.GetStringSetting(ctx, channel.UserId,
-		"autoreject_reply")
+// parseMinutes parses a number of minutes, where empty means zero.
+func parseMinutes(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+	minutes, err := strconv.Atoi(val)
 	if err != nil {
-		return err
+		return 0, Err.Wrap(err)
 	}
+	if minutes < 0 {
+		return 0, Err.New("negative number of minutes")
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
 
-	oldestCreationStr, err := s.db.GetStringSetting(ctx, channel.UserId,
-		"syncstart-"+channel.CalId)
+// parseDays parses a number of days, where empty means zero.
+func parseDays(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, Err.Wrap(err)
+	}
+	if days < 0 {
+		return 0, Err.New("negative number of days")
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// policy reads a policy's <prefix>_action and <prefix>_reply settings.
+// Policies without an action setting decline.
+func policy(settings map[string]string, prefix string, enabled bool) (
+	p reject.Policy, err error) {
+	p.Enabled = enabled
+	p.Action, err = reject.ParseAction(settings[prefix+"_action"])
+	if err != nil {
+		return p, err
+	}
+	p.Reply, err = reject.ParseReply(settings[prefix+"_reply"])
+	return p, err
+}
+
+// timeStore keeps a user's invite times in the DB.
+type timeStore struct {
+	db     *DB
+	userId string
+}
+
+func (t *timeStore) Get(ctx context.Context, calId string, eventIds []string) (
+	map[string]reject.EventTimes, error) {
+	return t.db.GetEventTimes(ctx, t.userId, calId, eventIds)
+}
+
+func (t *timeStore) Put(ctx context.Context, calId string,
+	times map[string]reject.EventTimes) error {
+	return t.db.PutEventTimes(ctx, t.userId, calId, times)
+}
+
+func (t *timeStore) Delete(ctx context.Context, calId string,
+	eventIds []string) error {
+	return t.db.DeleteEventTimes(ctx, t.userId, calId, eventIds)
+}
+
+func (s *Site) options(ctx context.Context, userId, calId string) (
+	opts reject.Options, err error) {
+	settings, err := s.db.GetStringSettings(ctx, userId, append(
+		[]string{"allowlist_temp", "syncstart-" + calId}, settingsFields...)...)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Log = &decisionLog{db: s.db, userId: userId}
+	opts.Times = &timeStore{db: s.db, userId: userId}
+	opts.MovedAccepted, err = reject.ParseMovedPolicy(settings["moved_accepted"])
+	if err != nil {
+		return opts, err
+	}
+	opts.RestoreStatus = settings["restore_status"]
+	opts.RestoreNotify = settings["restore_notify"] != ""
+	opts.RestoreReply, err = reject.ParseReply(settings["restore_reply"])
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Rules, err = rules(settings)
+	if err != nil {
+		return opts, err
+	}
+	opts.Reply, err = reject.ParseReply(settings["autoreject_reply"])
+	if err != nil {
+		return opts, err
+	}
+	opts.SuggestSlots, err = strconv.Atoi(settings["suggest_slots"])
+	if err != nil {
+		return opts, Err.Wrap(err)
+	}
+	opts.WorkingHours, err = reject.ParseSchedule(settings["working_hours"])
+	if err != nil {
+		return opts, err
+	}
+	opts.OutsideHours, err = policy(settings, "working_hours",
+		settings["working_hours_decline"] != "")
+	if err != nil {
+		return opts, err
+	}
+	opts.DailyLoad, err = parseHours(settings["load_daily_hours"])
+	if err != nil {
+		return opts, err
+	}
+	opts.WeeklyLoad, err = parseHours(settings["load_weekly_hours"])
+	if err != nil {
+		return opts, err
+	}
+	opts.OverLoad, err = policy(settings, "load",
+		opts.DailyLoad > 0 || opts.WeeklyLoad > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.FloatingBlocks, err = reject.ParseFloatingBlocks(
+		settings["floating_blocks"])
+	if err != nil {
+		return opts, err
+	}
+	opts.Floating, err = policy(settings, "floating",
+		len(opts.FloatingBlocks) > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.BackToBackLimit, err = parseHours(settings["backtoback_hours"])
+	if err != nil {
+		return opts, err
+	}
+	opts.BackToBackGap, err = parseMinutes(settings["backtoback_gap"])
+	if err != nil {
+		return opts, err
+	}
+	opts.BackToBack, err = policy(settings, "backtoback",
+		opts.BackToBackLimit > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.MinLeadTime, err = parseHours(settings["short_notice_hours"])
+	if err != nil {
+		return opts, err
+	}
+	opts.ShortNotice, err = policy(settings, "short_notice",
+		opts.MinLeadTime > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.MaxLeadTime, err = parseDays(settings["far_future_days"])
+	if err != nil {
+		return opts, err
+	}
+	opts.FarFuture, err = policy(settings, "far_future", opts.MaxLeadTime > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.NoAgenda, err = policy(settings, "agenda",
+		settings["agenda_required"] != "")
+	if err != nil {
+		return opts, err
+	}
+	opts.MaxDuration, err = parseMinutes(settings["duration_max_minutes"])
+	if err != nil {
+		return opts, err
+	}
+	opts.TooLong, err = policy(settings, "duration", opts.MaxDuration > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.NoConference, err = policy(settings, "conference",
+		settings["conference_required"] != "")
+	if err != nil {
+		return opts, err
+	}
+	opts.MaxAttendees, err = parseCount(settings["large_meeting_attendees"])
+	if err != nil {
+		return opts, err
+	}
+	opts.LargeMeeting, err = policy(settings, "large_meeting",
+		opts.MaxAttendees > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.Optional, err = policy(settings, "optional",
+		settings["optional_separate"] != "")
+	if err != nil {
+		return opts, err
+	}
+	opts.Optional.Notify, err = reject.ParseNotify(settings["optional_notify"])
+	if err != nil {
+		return opts, err
+	}
+	opts.Keywords, err = reject.ParseKeywordList(settings["keyword_denylist"])
+	if err != nil {
+		return opts, err
+	}
+	opts.KeywordDeny, err = policy(settings, "keyword", len(opts.Keywords) > 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.InternalDomains, err = reject.ParseAddressList(
+		settings["internal_domains"])
+	if err != nil {
+		return opts, err
+	}
+	opts.Allow, err = reject.ParseAddressList(settings["allowlist"])
+	if err != nil {
+		return opts, err
+	}
+	opts.Allow = append(opts.Allow,
+		tempAllowlist(settings["allowlist_temp"], time.Now()).active()...)
+	opts.Deny, err = reject.ParseAddressList(settings["denylist"])
+	if err != nil {
+		return opts, err
+	}
+
+	if syncStart := settings["syncstart-"+calId]; syncStart != "" {
+		opts.OldestCreation, err = time.Parse(time.RFC3339, syncStart)
+		if err != nil {
+			return opts, Err.Wrap(err)
+		}
+	}
+
+	return opts, nil
+}
+
+func (s *Site) sync(ctx context.Context, chanId string, channel *DSChannel) error {
+	syncToken, err := s.db.GetStringSetting(ctx, channel.UserId,
+		"synctoken-"+channel.CalId)
 	if err != nil {
 		return err
 	}
-	oldestCreation, err := time.Parse(time.RFC3339, oldestCreationStr)
+
+	opts, err := s.options(ctx, channel.UserId, channel.CalId)
 	if err != nil {
-		return Err.Wrap(err)
+		return err
 	}
 
 	tok, err := s.db.GetUserOAuth2Token(ctx, channel.UserId)
@@ -52,20 +337,41 @@ func (s *Site) sync(ctx context.Context, chanId string, channel *DSChannel) erro
 		return Err.Wrap(err)
 	}
 
-	return reject.RejectBadInvites(
-		ctx, srv, channel.CalId, syncToken, func(e *calendar.Event) bool {
-			if !strings.Contains(strings.ToLower(e.Summary), autorejectName) {
-				return false
-			}
-			if len(e.Attendees) != 0 {
-				return false
-			}
-			return true
-		}, autorejectReply,
-		oldestCreation, func(ctx context.Context, nextSyncToken string) error {
+	_, err = reject.RejectBadInvites(ctx, srv, channel.CalId, syncToken, opts,
+		func(ctx context.Context, nextSyncToken string) error {
 			return s.db.SetStringSetting(
 				ctx, channel.UserId, "synctoken-"+channel.CalId, nextSyncToken)
 		})
+	return err
+}
+
+// Preview shows what autoreject would do with the upcoming pending invites
+// on a calendar without responding to any of them.
+func (s *Site) Preview(w http.ResponseWriter, r *http.Request) {
+	ctx := whcompat.Context(r)
+	calId := r.FormValue("cal")
+
+	opts, err := s.options(ctx, s.UserId(ctx), calId)
+	if err != nil {
+		whfatal.Error(err)
+	}
+	opts.DryRun = true
+	opts.Upcoming = reject.PreviewHorizon
+
+	srv, err := calendar.New(s.OAuth2Client(ctx))
+	if err != nil {
+		whfatal.Error(Err.Wrap(err))
+	}
+
+	decisions, err := reject.RejectBadInvites(ctx, srv, calId, "", opts, nil)
+	if err != nil {
+		whfatal.Error(err)
+	}
+
+	s.r.Render(w, r, "preview", map[string]interface{}{
+		"CalId":     calId,
+		"Decisions": decisions,
+	})
 }
 
 func (s *Site) Event(w http.ResponseWriter, r *http.Request) {
@@ -1,12 +1,34 @@
 module github.com/jtolio/autoreject
 
-go 1.13
+go 1.19
 
 require (
-	cloud.google.com/go/datastore v1.1.0
+	cloud.google.com/go/datastore v1.10.0
 	github.com/spacemonkeygo/errors v0.0.0-20171212215202-9064522e9fd1
-	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
-	google.golang.org/api v0.32.0
+	golang.org/x/oauth2 v0.6.0
+	google.golang.org/api v0.114.0
 	gopkg.in/go-webhelp/whoauth2.v1 v1.0.0-20200923055940-053b2b05c515
 	gopkg.in/webhelp.v1 v1.0.0-20170530084242-3f30213e4c49
 )
+
+require (
+	cloud.google.com/go v0.110.0 // indirect
+	cloud.google.com/go/compute v1.18.0 // indirect
+	cloud.google.com/go/compute/metadata v0.2.3 // indirect
+	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/google/go-cmp v0.5.9 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/googleapis/enterprise-certificate-proxy v0.2.3 // indirect
+	github.com/googleapis/gax-go/v2 v2.7.1 // indirect
+	go.opencensus.io v0.24.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
+	golang.org/x/net v0.8.0 // indirect
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
+	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/genproto v0.0.0-20230306155012-7f2fa6fef1f4 // indirect
+	google.golang.org/grpc v1.53.0 // indirect
+	google.golang.org/protobuf v1.29.1 // indirect
+)
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/webhelp.v1/whcompat"
+	"gopkg.in/webhelp.v1/wherr"
+	"gopkg.in/webhelp.v1/whfatal"
+)
+
+const historyPageSize = 50
+
+func parseDateParam(r *http.Request, name string) time.Time {
+	val := r.FormValue(name)
+	if val == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02", val)
+	if err != nil {
+		whfatal.Error(wherr.BadRequest.Wrap(err))
+	}
+	return t
+}
+
+func (s *Site) History(w http.ResponseWriter, r *http.Request) {
+	ctx := whcompat.Context(r)
+
+	filter := DecisionFilter{
+		From:      parseDateParam(r, "from"),
+		To:        parseDateParam(r, "to"),
+		Organizer: strings.ToLower(strings.TrimSpace(r.FormValue("organizer"))),
+	}
+	if !filter.To.IsZero() {
+		// the to date is inclusive
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+
+	decisions, next, err := s.db.Decisions(ctx, s.UserId(ctx), filter,
+		r.FormValue("cursor"), historyPageSize)
+	if err != nil {
+		whfatal.Error(err)
+	}
+
+	s.r.Render(w, r, "history", map[string]interface{}{
+		"From":      r.FormValue("from"),
+		"To":        r.FormValue("to"),
+		"Organizer": filter.Organizer,
+		"Decisions": decisions,
+		"Next":      next,
+	})
+}
@@ -8,7 +8,11 @@ import (
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jtolio/autoreject/reject"
 	"github.com/jtolio/autoreject/views"
 	"github.com/spacemonkeygo/errors"
 	"golang.org/x/oauth2"
@@ -19,6 +23,7 @@ import (
 	"gopkg.in/webhelp.v1"
 	"gopkg.in/webhelp.v1/whcache"
 	"gopkg.in/webhelp.v1/whcompat"
+	"gopkg.in/webhelp.v1/wherr"
 	"gopkg.in/webhelp.v1/whfatal"
 	"gopkg.in/webhelp.v1/whlog"
 	"gopkg.in/webhelp.v1/whmux"
@@ -102,10 +107,201 @@ func (s *Site) UserId(ctx context.Context) string {
 	return ti.UserId
 }
 
+var settingsFields = []string{
+	"autoreject_name", "autoreject_reply", "autoreject_rules",
+	"native_outofoffice", "native_focustime", "native_working_location",
+	"suggest_slots", "working_hours", "working_hours_decline",
+	"working_hours_reply", "load_daily_hours", "load_weekly_hours",
+	"load_reply", "floating_blocks", "floating_reply",
+	"backtoback_hours", "backtoback_gap", "backtoback_reply",
+	"short_notice_hours", "short_notice_action", "short_notice_reply",
+	"far_future_days", "far_future_action", "far_future_reply",
+	"agenda_required", "agenda_action", "agenda_reply",
+	"duration_max_minutes", "duration_action", "duration_reply",
+	"conference_required", "conference_action", "conference_reply",
+	"large_meeting_attendees", "large_meeting_action", "large_meeting_reply",
+	"internal_domains", "optional_separate", "optional_action",
+	"optional_notify", "optional_reply", "keyword_denylist", "keyword_reply",
+	"allowlist", "denylist",
+	"restore_status", "restore_notify", "restore_reply", "moved_accepted"}
+
+// settingKind validates the values of one kind of setting.
+type settingKind func(val string) error
+
+func replySetting(val string) error {
+	_, err := reject.ParseReply(val)
+	return err
+}
+
+func actionSetting(val string) error {
+	_, err := reject.ParseAction(val)
+	return err
+}
+
+func notifySetting(val string) error {
+	_, err := reject.ParseNotify(val)
+	return err
+}
+
+func hoursSetting(val string) error {
+	_, err := parseHours(val)
+	return err
+}
+
+func minutesSetting(val string) error {
+	_, err := parseMinutes(val)
+	return err
+}
+
+func daysSetting(val string) error {
+	_, err := parseDays(val)
+	return err
+}
+
+func countSetting(val string) error {
+	_, err := parseCount(val)
+	return err
+}
+
+func addressesSetting(val string) error {
+	_, err := reject.ParseAddressList(val)
+	return err
+}
+
+func keywordsSetting(val string) error {
+	_, err := reject.ParseKeywordList(val)
+	return err
+}
+
+func scheduleSetting(val string) error {
+	_, err := reject.ParseSchedule(val)
+	return err
+}
+
+func floatingSetting(val string) error {
+	_, err := reject.ParseFloatingBlocks(val)
+	return err
+}
+
+func movedSetting(val string) error {
+	_, err := reject.ParseMovedPolicy(val)
+	return err
+}
+
+func rulesSetting(val string) error {
+	if strings.TrimSpace(val) == "" {
+		return nil
+	}
+	_, err := reject.ParseRuleSet([]byte(val))
+	return err
+}
+
+func workingLocationSetting(val string) error {
+	switch val {
+	case "", "notInOffice", "homeOffice", "customLocation":
+		return nil
+	}
+	return Err.New("unknown working location %q", val)
+}
+
+func suggestSlotsSetting(val string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return Err.Wrap(err)
+	}
+	if n < 0 || n > 10 {
+		return Err.New("suggest between 0 and 10 free slots")
+	}
+	return nil
+}
+
+func restoreStatusSetting(val string) error {
+	if val != "needsAction" && val != "accepted" {
+		return Err.New("unknown restore status %q", val)
+	}
+	return nil
+}
+
+// settingsKinds are checked before settings values are saved, so they can't
+// break a sync later. Settings that aren't listed are free text or
+// checkboxes.
+var settingsKinds = map[string]settingKind{
+	"autoreject_reply":        replySetting,
+	"autoreject_rules":        rulesSetting,
+	"native_working_location": workingLocationSetting,
+	"suggest_slots":           suggestSlotsSetting,
+	"working_hours":           scheduleSetting,
+	"working_hours_reply":     replySetting,
+	"load_daily_hours":        hoursSetting,
+	"load_weekly_hours":       hoursSetting,
+	"load_reply":              replySetting,
+	"floating_blocks":         floatingSetting,
+	"floating_reply":          replySetting,
+	"backtoback_hours":        hoursSetting,
+	"backtoback_gap":          minutesSetting,
+	"backtoback_reply":        replySetting,
+	"short_notice_hours":      hoursSetting,
+	"short_notice_action":     actionSetting,
+	"short_notice_reply":      replySetting,
+	"far_future_days":         daysSetting,
+	"far_future_action":       actionSetting,
+	"far_future_reply":        replySetting,
+	"agenda_action":           actionSetting,
+	"agenda_reply":            replySetting,
+	"duration_max_minutes":    minutesSetting,
+	"duration_action":         actionSetting,
+	"duration_reply":          replySetting,
+	"conference_action":       actionSetting,
+	"conference_reply":        replySetting,
+	"large_meeting_attendees": countSetting,
+	"large_meeting_action":    actionSetting,
+	"large_meeting_reply":     replySetting,
+	"internal_domains":        addressesSetting,
+	"optional_action":         actionSetting,
+	"optional_notify":         notifySetting,
+	"optional_reply":          replySetting,
+	"keyword_denylist":        keywordsSetting,
+	"keyword_reply":           replySetting,
+	"allowlist":               addressesSetting,
+	"denylist":                addressesSetting,
+	"restore_status":          restoreStatusSetting,
+	"restore_reply":           replySetting,
+	"moved_accepted":          movedSetting,
+}
+
+// weekdays are the days of the working hours editor, in the order
+// reject.ParseSchedule names them.
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// workingHoursForm combines the working hours editor's per day fields into
+// the working_hours setting.
+func workingHoursForm(r *http.Request) string {
+	var entries []string
+	for _, day := range weekdays {
+		if ranges := strings.TrimSpace(r.FormValue("working_hours_" + day)); ranges != "" {
+			entries = append(entries, day+" "+strings.ReplaceAll(ranges, " ", ""))
+		}
+	}
+	return strings.Join(entries, "; ")
+}
+
 func (s *Site) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 	ctx := whcompat.Context(r)
 
-	for _, field := range []string{"autoreject_name", "autoreject_reply"} {
+	err := r.ParseForm()
+	if err != nil {
+		whfatal.Error(wherr.BadRequest.Wrap(err))
+	}
+	r.Form.Set("working_hours", workingHoursForm(r))
+
+	for field, validate := range settingsKinds {
+		err := validate(r.FormValue(field))
+		if err != nil {
+			whfatal.Error(wherr.BadRequest.Wrap(err))
+		}
+	}
+
+	for _, field := range settingsFields {
 		val := r.FormValue(field)
 
 		err := s.db.SetStringSetting(ctx, s.UserId(ctx), field, val)
@@ -175,14 +371,29 @@ func (s *Site) Settings(w http.ResponseWriter, r *http.Request) {
 		"Calendars": calendars,
 	}
 
-	for _, field := range []string{"autoreject_name", "autoreject_reply"} {
-		val, err := s.db.GetStringSetting(ctx, s.UserId(ctx), field)
-		if err != nil {
-			whfatal.Error(err)
-		}
+	settings, err := s.db.GetStringSettings(ctx, s.UserId(ctx),
+		settingsFields...)
+	if err != nil {
+		whfatal.Error(err)
+	}
+	for field, val := range settings {
 		values[field] = val
 	}
 
+	type workingDay struct {
+		Day, Ranges string
+	}
+	var workingDays []workingDay
+	hours, err := reject.ParseSchedule(values["working_hours"].(string))
+	if err != nil {
+		whfatal.Error(err)
+	}
+	for i, day := range weekdays {
+		workingDays = append(workingDays, workingDay{
+			Day: day, Ranges: hours.Ranges(time.Weekday(i))})
+	}
+	values["WorkingDays"] = workingDays
+
 	s.r.Render(w, r, "settings", values)
 }
 
@@ -238,6 +449,15 @@ func main() {
 								"GET":  http.HandlerFunc(site.Settings),
 								"POST": http.HandlerFunc(site.UpdateSettings),
 							})),
+						"history": site.LoginRequired(whmux.ExactPath(
+							whmux.RequireMethod("GET",
+								http.HandlerFunc(site.History)))),
+						"undo": site.LoginRequired(whmux.ExactPath(
+							whmux.RequireMethod("POST",
+								http.HandlerFunc(site.Undo)))),
+						"preview": site.LoginRequired(whmux.ExactPath(
+							whmux.RequireMethod("GET",
+								http.HandlerFunc(site.Preview)))),
 						"register": site.LoginRequired(whmux.ExactPath(
 							whmux.RequireMethod("POST",
 								http.HandlerFunc(site.Register)))),
@@ -0,0 +1,50 @@
+package reject
+
+import (
+	"strings"
+)
+
+// AddressList is a list of email addresses and domains. A domain also
+// covers its subdomains.
+type AddressList []string
+
+// ParseAddressList parses addresses and domains separated by commas or
+// whitespace. Domains may be written as "example.com" or "@example.com".
+func ParseAddressList(s string) (l AddressList, err error) {
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\r' ||
+			r == '\n'
+	}) {
+		entry = strings.TrimPrefix(strings.ToLower(entry), "@")
+		if entry == "" {
+			continue
+		}
+		if strings.Count(entry, "@") > 1 || strings.HasSuffix(entry, "@") ||
+			!strings.Contains(entry, ".") {
+			return nil, Err.New("invalid address or domain %q", entry)
+		}
+		l = append(l, entry)
+	}
+	return l, nil
+}
+
+// Match returns the entry that covers email, or "" if none does.
+func (l AddressList) Match(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, entry := range l {
+		if strings.Contains(entry, "@") {
+			if entry == email {
+				return entry
+			}
+			continue
+		}
+		if domain == entry || strings.HasSuffix(domain, "."+entry) {
+			return entry
+		}
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package reject
+
+import (
+	"strings"
+)
+
+// DefaultLargeMeetingReply is the reply used for invites with too many
+// attendees unless one is configured.
+const DefaultLargeMeetingReply = "Automatic response - this is a large " +
+	"meeting, so I may not make it. Please share notes afterwards."
+
+// attendeeCount returns how many people, not rooms or other resources, are
+// invited to c.
+func attendeeCount(c candidate) (n int) {
+	for _, attendee := range c.item.Attendees {
+		if !attendee.Resource {
+			n++
+		}
+	}
+	return n
+}
+
+// internal returns whether c's organizer is in InternalDomains, or if that
+// is empty, in the user's own domain.
+func (r *rejecter) internal(c candidate) bool {
+	domains := r.opts.InternalDomains
+	if len(domains) == 0 {
+		self := selfAttendee(c.item)
+		if self == nil {
+			return false
+		}
+		domains = AddressList{self.Email[strings.LastIndex(self.Email, "@")+1:]}
+	}
+	return domains.Match(organizerEmail(c.item)) != ""
+}
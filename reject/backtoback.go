@@ -0,0 +1,51 @@
+package reject
+
+import (
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// DefaultBackToBackReply is the reply used for invites that would make too
+// long a run of back-to-back meetings unless one is configured.
+const DefaultBackToBackReply = "Automatic decline - this would make for " +
+	"{{.Reason}}." + slotsSuffix
+
+// joinRanges is mergeRanges, but also joins ranges less than gap apart.
+func joinRanges(ranges []timeRange, gap time.Duration) (rv []timeRange) {
+	for _, r := range mergeRanges(ranges) {
+		if len(rv) > 0 && r.start.Sub(rv[len(rv)-1].end) < gap {
+			rv[len(rv)-1].end = r.end
+			continue
+		}
+		rv = append(rv, r)
+	}
+	return rv
+}
+
+// backToBack returns whether accepting c would put it in a run of
+// back-to-back meetings longer than BackToBackLimit. All-day invites never
+// do.
+func (r *rejecter) backToBack(c candidate, loc *time.Location,
+	events []*calendar.Event) (bool, error) {
+	if r.opts.BackToBackLimit <= 0 || c.allDay() {
+		return false, nil
+	}
+	busy := []timeRange{{start: c.start, end: c.end}}
+	for _, e := range events {
+		if e.Id == c.item.Id || !r.countsTowardLoad(e) {
+			continue
+		}
+		start, end, err := eventSpan(e, loc)
+		if err != nil {
+			return false, err
+		}
+		busy = append(busy, timeRange{start: start, end: end})
+	}
+	for _, run := range joinRanges(busy, r.opts.BackToBackGap) {
+		if overlaps(run.start, run.end, c.start, c.end) {
+			return run.end.Sub(run.start) > r.opts.BackToBackLimit, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,228 @@
+package reject
+
+import (
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Action is what RejectBadInvites does with an invite.
+type Action string
+
+const (
+	ActionDecline   Action = "decline"
+	ActionTentative Action = "tentative"
+	// ActionColor leaves the response alone and only recolors the invite.
+	ActionColor Action = "color"
+	// ActionSkip leaves the invite alone.
+	ActionSkip Action = "skip"
+	// ActionRestore withdraws an earlier response.
+	ActionRestore Action = "restore"
+)
+
+// strength orders actions so the strongest of several applies.
+func (a Action) strength() int {
+	switch a {
+	case ActionDecline:
+		return 3
+	case ActionTentative:
+		return 2
+	case ActionColor:
+		return 1
+	}
+	return 0
+}
+
+// Response configures how RejectBadInvites responds to an invite.
+type Response struct {
+	// Action defaults to ActionDecline.
+	Action Action `json:"action,omitempty"`
+	// Color is the colorId given to the invite by ActionColor.
+	Color string `json:"color,omitempty"`
+	// Notify is who gets told about the response: "all" (the default),
+	// "externalOnly" or "none".
+	Notify string `json:"notify,omitempty"`
+}
+
+func (r Response) validate() error {
+	switch r.Action {
+	case "", ActionDecline, ActionTentative:
+	case ActionColor:
+		if r.Color == "" {
+			return Err.New("the color action needs a color")
+		}
+	default:
+		return Err.New("unknown action %q", r.Action)
+	}
+	switch r.Notify {
+	case "", "all", "externalOnly", "none":
+	default:
+		return Err.New("unknown notify setting %q", r.Notify)
+	}
+	return nil
+}
+
+// ParseAction parses an action for a policy, which can be "decline" (the
+// default if empty) or "tentative".
+func ParseAction(s string) (Action, error) {
+	switch a := Action(s); a {
+	case "":
+		return ActionDecline, nil
+	case ActionDecline, ActionTentative:
+		return a, nil
+	}
+	return "", Err.New("unknown action %q", s)
+}
+
+// ParseNotify parses who is told about a response: "all" (the default if
+// empty), "externalOnly" or "none".
+func ParseNotify(s string) (string, error) {
+	r := Response{Notify: strings.TrimSpace(s)}
+	return r.notify(), r.validate()
+}
+
+func (r Response) action() Action {
+	if r.Action == "" {
+		return ActionDecline
+	}
+	return r.Action
+}
+
+func (r Response) notify() string {
+	if r.Notify == "" {
+		return "all"
+	}
+	return r.Notify
+}
+
+var directiveReplacer = strings.NewReplacer("<br>", "\n", "<br/>", "\n",
+	"<br />", "\n")
+
+// blockerResponse is the rule's response with any overrides from lines like
+// "autoreject-action: tentative", "autoreject-color: 5" or
+// "autoreject-notify: none" in the blocker's description.
+func blockerResponse(blocker *calendar.Event, rule *Rule) Response {
+	resp := rule.Response
+	desc := directiveReplacer.Replace(blocker.Description)
+	for _, line := range strings.Split(desc, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		override := resp
+		val := strings.TrimSpace(parts[1])
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "autoreject-action":
+			override.Action = Action(strings.ToLower(val))
+		case "autoreject-color":
+			override.Color = val
+		case "autoreject-notify":
+			override.Notify = val
+		default:
+			continue
+		}
+		if override.validate() == nil {
+			resp = override
+		}
+	}
+	return resp
+}
+
+// Decision records what RejectBadInvites did, or in a dry run would have
+// done, with an invite and why.
+type Decision struct {
+	// Id is assigned by the DecisionLog.
+	Id        string
+	Time      time.Time
+	CalId     string
+	InviteId  string
+	Summary   string
+	Organizer string
+	Start     time.Time
+	End       time.Time
+	BlockerId string
+	Blocker   string
+	Rule      string
+	// ListEntry is the allowlist or denylist entry that decided the invite.
+	ListEntry string
+	// Policy explains which policy the invite failed, if that decided it.
+	Policy string
+	// Keyword is the keyword denylist entry that decided the invite.
+	Keyword string
+	Action  Action
+	// Optional is set when the user was an optional attendee and the
+	// response for optional invites was used.
+	Optional bool
+	// Series is set when InviteId is a whole recurring series, responded to
+	// because of the instance from Start to End.
+	Series bool
+	// Suggestions are the free slots offered in the decline comment.
+	Suggestions []time.Time
+	DryRun      bool
+	// Undone is set once the user has undone the decision by hand.
+	Undone bool
+	// Resolved is set once the decision no longer stands, because it was
+	// undone, withdrawn or handed to another blocker. Decisions that aren't
+	// Standing are always resolved.
+	Resolved bool
+}
+
+// Standing returns whether the decision left a response on the invite that
+// may later be withdrawn.
+func (d *Decision) Standing() bool {
+	return d.BlockerId != "" &&
+		(d.Action == ActionDecline || d.Action == ActionTentative)
+}
+
+// Reason explains what decided the invite.
+func (d *Decision) Reason() string {
+	switch {
+	case d.ListEntry != "":
+		return "list entry " + d.ListEntry
+	case d.Keyword != "":
+		return "keyword " + d.Keyword
+	case d.Policy != "":
+		return d.Policy
+	case d.Rule != "":
+		return "blocker " + d.Blocker + " (rule " + d.Rule + ")"
+	}
+	return "blocker " + d.Blocker
+}
+
+// Undoable returns whether the user can still take back the response the
+// decision left on the invite. Standing decisions that were resolved have
+// already been withdrawn or handed to another blocker's decision.
+func (d *Decision) Undoable() bool {
+	return !d.DryRun && !d.Undone && !(d.Standing() && d.Resolved) &&
+		(d.Action == ActionDecline || d.Action == ActionTentative)
+}
+
+// selfAttendee returns the user's own attendee entry on e, or nil if e is
+// not an invite to them.
+func selfAttendee(e *calendar.Event) *calendar.EventAttendee {
+	for _, attendee := range e.Attendees {
+		if attendee.Self {
+			return attendee
+		}
+	}
+	return nil
+}
+
+// invitee is selfAttendee, but nil for events the user organizes, which are
+// never theirs to respond to.
+func invitee(e *calendar.Event) *calendar.EventAttendee {
+	self := selfAttendee(e)
+	if self == nil || self.Organizer ||
+		(e.Organizer != nil && e.Organizer.Self) {
+		return nil
+	}
+	return self
+}
+
+func organizerEmail(e *calendar.Event) string {
+	if e.Organizer == nil {
+		return ""
+	}
+	return e.Organizer.Email
+}
@@ -0,0 +1,155 @@
+package reject
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// fakeCalendar serves just enough of the Calendar API for RejectBadInvites.
+// Queries without a time range act as a sync and return every event.
+// Instance queries return the events with that RecurringEventId. Syncs from
+// a sync token return changes instead, if it is set.
+type fakeCalendar struct {
+	mu       sync.Mutex
+	timeZone string
+	events   []*calendar.Event
+	changes  []*calendar.Event
+	lists    int
+	patches  int
+	patched  []*calendar.Event
+	// bodies are the patches as sent, and updates their sendUpdates.
+	bodies  []string
+	updates []string
+}
+
+func (f *fakeCalendar) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(req.URL.Path, "/calendars/") {
+		http.NotFound(w, req)
+		return
+	}
+
+	parts := strings.Split(req.URL.Path, "/")
+	switch {
+	case req.Method == "GET" && len(parts) == 5 && parts[3] == "events":
+		for _, e := range f.events {
+			if e.Id == parts[4] {
+				_ = json.NewEncoder(w).Encode(e)
+				return
+			}
+		}
+		http.NotFound(w, req)
+	case req.Method == "GET":
+		f.lists++
+		loc, err := calendarLocation(f.timeZone)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		events := f.events
+		if strings.HasSuffix(req.URL.Path, "/instances") {
+			events = nil
+			for _, e := range f.events {
+				if e.RecurringEventId == parts[len(parts)-2] {
+					events = append(events, e)
+				}
+			}
+		}
+		resp := &calendar.Events{TimeZone: f.timeZone}
+		q := req.URL.Query()
+		if q.Get("timeMin") == "" {
+			resp.Items = events
+			if q.Get("syncToken") != "" && f.changes != nil {
+				resp.Items = f.changes
+			}
+			resp.NextSyncToken = "next"
+		} else {
+			min, _ := time.Parse(time.RFC3339, q.Get("timeMin"))
+			max, _ := time.Parse(time.RFC3339, q.Get("timeMax"))
+			for _, e := range events {
+				start, end, err := eventSpan(e, loc)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if overlaps(start, end, min, max) {
+					resp.Items = append(resp.Items, e)
+				}
+			}
+			sort.SliceStable(resp.Items, func(i, j int) bool {
+				a, _ := parseTime(resp.Items[i].Start, loc)
+				b, _ := parseTime(resp.Items[j].Start, loc)
+				return a.Before(b)
+			})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case req.Method == "PATCH":
+		f.patches++
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var patch calendar.Event
+		err = json.Unmarshal(body, &patch)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.patched = append(f.patched, &patch)
+		f.bodies = append(f.bodies, string(body))
+		f.updates = append(f.updates, req.URL.Query().Get("sendUpdates"))
+		_ = json.NewEncoder(w).Encode(&patch)
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeCalendar) service(tb testing.TB) *calendar.Service {
+	ts := httptest.NewServer(f)
+	tb.Cleanup(ts.Close)
+	srv, err := calendar.NewService(context.Background(),
+		option.WithEndpoint(ts.URL+"/"), option.WithHTTPClient(ts.Client()))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return srv
+}
+
+func (f *fakeCalendar) addBlocker(id string, start, end time.Time) {
+	f.events = append(f.events, &calendar.Event{
+		Id:      id,
+		Summary: "busy (autoreject)",
+		Created: start.Add(-time.Hour).Format(time.RFC3339),
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: end.Format(time.RFC3339)},
+	})
+}
+
+func (f *fakeCalendar) addInvite(id string, created, start, end time.Time) {
+	f.events = append(f.events, &calendar.Event{
+		Id:        id,
+		Summary:   "meeting " + id,
+		Created:   created.Format(time.RFC3339),
+		Organizer: &calendar.EventOrganizer{Email: "organizer@example.com"},
+		Start:     &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:       &calendar.EventDateTime{DateTime: end.Format(time.RFC3339)},
+		Attendees: []*calendar.EventAttendee{{
+			Email: "organizer@example.com", Organizer: true,
+			ResponseStatus: "accepted"}, {
+			Email: "me@example.com", Self: true, ResponseStatus: "needsAction"}},
+	})
+}
@@ -0,0 +1,159 @@
+package reject
+
+import (
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// DefaultFloatingReply is the reply used for invites that would take the
+// last free slot of a floating block unless one is configured.
+const DefaultFloatingReply = "Automatic decline - I keep some time free " +
+	"around then." + slotsSuffix
+
+// FloatingBlock keeps at least Length of contiguous free time somewhere in
+// Window on the given days, in the calendar's time zone.
+type FloatingBlock struct {
+	Days   [7]bool
+	Window Span
+	Length time.Duration
+}
+
+// ParseFloatingBlocks parses lines or semicolon separated entries like
+// "mon-fri 11:30-14:00 45m".
+func ParseFloatingBlocks(s string) (blocks []FloatingBlock, err error) {
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ';' || r == '\n'
+	}) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Fields(entry)
+		if len(fields) != 3 {
+			return nil, Err.New("invalid floating block %q", entry)
+		}
+		var block FloatingBlock
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			block.Days[day] = true
+		}
+		block.Window, err = parseSpan(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		block.Length, err = time.ParseDuration(fields[2])
+		if err != nil {
+			return nil, Err.Wrap(err)
+		}
+		if block.Length <= 0 || block.Length > block.Window.End-block.Window.Start {
+			return nil, Err.New("floating block %q doesn't fit its window", entry)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func (b FloatingBlock) String() string {
+	return formatDuration(b.Length) + " free between " +
+		formatTimeOfDay(b.Window.Start) + " and " + formatTimeOfDay(b.Window.End)
+}
+
+// hasFreeSlot returns whether busy, which must be merged, leaves length free
+// somewhere in [start, end).
+func hasFreeSlot(busy []timeRange, start, end time.Time,
+	length time.Duration) bool {
+	free := start
+	for _, span := range busy {
+		if !span.end.After(free) {
+			continue
+		}
+		if !span.start.Before(end) {
+			break
+		}
+		if span.start.Sub(free) >= length {
+			return true
+		}
+		free = span.end
+	}
+	return end.Sub(free) >= length
+}
+
+// busySpan returns the time e takes up, if any. Blockers do, with their
+// buffers, and so do other opaque events unless the user declined them or
+// hasn't responded yet.
+func (r *rejecter) busySpan(e *calendar.Event, loc *time.Location) (
+	span timeRange, busy bool, err error) {
+	if e.Status == "cancelled" {
+		return span, false, nil
+	}
+	rule := r.opts.Rules.Matches(r.calId, e)
+	if rule == nil {
+		if e.Transparency == "transparent" {
+			return span, false, nil
+		}
+		if self := selfAttendee(e); self != nil {
+			switch self.ResponseStatus {
+			case "accepted", "tentative":
+			default:
+				return span, false, nil
+			}
+		}
+	}
+	span.start, span.end, err = eventSpan(e, loc)
+	if err != nil {
+		return span, false, err
+	}
+	if rule != nil {
+		span.start, span.end = rule.pad(span.start, span.end)
+	}
+	return span, true, nil
+}
+
+// takesFloatingBlock returns the floating block whose last qualifying free
+// slot c would take, if any. All-day invites don't take up any slots.
+func (r *rejecter) takesFloatingBlock(c candidate, loc *time.Location,
+	events []*calendar.Event) (*FloatingBlock, error) {
+	if len(r.opts.FloatingBlocks) == 0 || c.allDay() {
+		return nil, nil
+	}
+	var busy []timeRange
+	for _, e := range events {
+		if e.Id == c.item.Id {
+			continue
+		}
+		span, ok, err := r.busySpan(e, loc)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			busy = append(busy, span)
+		}
+	}
+	without := mergeRanges(busy)
+	with := mergeRanges(append(busy, timeRange{start: c.start, end: c.end}))
+
+	for day, _ := dayOf(c.start, loc); day.Before(c.end); day = time.Date(
+		day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		for i := range r.opts.FloatingBlocks {
+			block := &r.opts.FloatingBlocks[i]
+			if !block.Days[day.Weekday()] {
+				continue
+			}
+			start := atTimeOfDay(day, block.Window.Start)
+			end := atTimeOfDay(day, block.Window.End)
+			if !overlaps(c.start, c.end, start, end) {
+				continue
+			}
+			if hasFreeSlot(without, start, end, block.Length) &&
+				!hasFreeSlot(with, start, end, block.Length) {
+				return block, nil
+			}
+		}
+	}
+	return nil, nil
+}
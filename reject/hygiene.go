@@ -0,0 +1,35 @@
+package reject
+
+import (
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Default replies for the meeting hygiene policies.
+const (
+	DefaultNoAgendaReply = "Automatic decline - please add an agenda and " +
+		"re-send."
+	DefaultTooLongReply = "Automatic decline - this is longer than I can " +
+		"commit to. Please consider a shorter meeting."
+	DefaultNoConferenceReply = "Automatic decline - please add a video call " +
+		"or a location and re-send."
+)
+
+func hasAgenda(e *calendar.Event) bool {
+	return strings.TrimSpace(directiveReplacer.Replace(e.Description)) != ""
+}
+
+func hasConference(e *calendar.Event) bool {
+	return e.ConferenceData != nil || e.HangoutLink != "" ||
+		strings.TrimSpace(e.Location) != ""
+}
+
+// tooLong returns whether c is longer than MaxDuration. All-day invites
+// never are.
+func (r *rejecter) tooLong(c candidate) bool {
+	if c.allDay() {
+		return false
+	}
+	return c.end.Sub(c.start) > r.opts.MaxDuration
+}
@@ -0,0 +1,53 @@
+package reject
+
+import (
+	"sort"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+type interval struct {
+	start, end time.Time
+	event      *calendar.Event
+	rule       *Rule
+	resp       Response
+}
+
+// intervalIndex answers overlap queries over a fixed set of intervals.
+type intervalIndex struct {
+	items []interval // sorted by start
+	// maxEnd[i] is the latest end of items[:i+1]
+	maxEnd []time.Time
+}
+
+func newIntervalIndex(items []interval) *intervalIndex {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].start.Before(items[j].start)
+	})
+	maxEnd := make([]time.Time, len(items))
+	for i, item := range items {
+		maxEnd[i] = item.end
+		if i > 0 && maxEnd[i-1].After(item.end) {
+			maxEnd[i] = maxEnd[i-1]
+		}
+	}
+	return &intervalIndex{items: items, maxEnd: maxEnd}
+}
+
+// overlapping returns the intervals that overlap [start, end), ordered by
+// start.
+func (idx *intervalIndex) overlapping(start, end time.Time) (rv []interval) {
+	hi := sort.Search(len(idx.items), func(i int) bool {
+		return !idx.items[i].start.Before(end)
+	})
+	for i := hi - 1; i >= 0 && idx.maxEnd[i].After(start); i-- {
+		if idx.items[i].end.After(start) {
+			rv = append(rv, idx.items[i])
+		}
+	}
+	for i, j := 0, len(rv)-1; i < j; i, j = i+1, j-1 {
+		rv[i], rv[j] = rv[j], rv[i]
+	}
+	return rv
+}
@@ -0,0 +1,55 @@
+package reject
+
+import (
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// DefaultKeywordReply is the reply used for invites declined by the keyword
+// denylist unless one is configured.
+const DefaultKeywordReply = "Automatic decline - I don't take these " +
+	"meetings."
+
+type keyword struct {
+	entry string
+	re    *regexp.Regexp
+}
+
+// KeywordList is a list of patterns matched against invite titles and
+// descriptions, case insensitively.
+type KeywordList []keyword
+
+// ParseKeywordList parses one pattern per line. A pattern is plain text to
+// look for, or a regular expression written like /quick ?sync/.
+func ParseKeywordList(s string) (l KeywordList, err error) {
+	for _, line := range strings.Split(s, "\n") {
+		entry := strings.TrimSpace(line)
+		if entry == "" {
+			continue
+		}
+		expr := regexp.QuoteMeta(entry)
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") &&
+			strings.HasSuffix(entry, "/") {
+			expr = entry[1 : len(entry)-1]
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, Err.Wrap(err)
+		}
+		l = append(l, keyword{entry: entry, re: re})
+	}
+	return l, nil
+}
+
+// Match returns the first entry that matches e's title or description, or
+// "" if none do.
+func (l KeywordList) Match(e *calendar.Event) string {
+	for _, k := range l {
+		if k.re.MatchString(e.Summary) || k.re.MatchString(e.Description) {
+			return k.entry
+		}
+	}
+	return ""
+}
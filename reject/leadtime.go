@@ -0,0 +1,24 @@
+package reject
+
+import (
+	"time"
+)
+
+// DefaultShortNoticeReply and DefaultFarFutureReply are the replies used for
+// invites with too little or too much lead time unless others are
+// configured.
+const (
+	DefaultShortNoticeReply = "Automatic decline - this is too short notice " +
+		"for me to make it." + slotsSuffix
+	DefaultFarFutureReply = "Automatic decline - this is too far out for me " +
+		"to commit to yet. Please send it again closer to the date."
+)
+
+// leadTime returns how long before c starts it was sent or last moved.
+func (r *rejecter) leadTime(c candidate) (time.Duration, error) {
+	scheduled, err := r.lastScheduled(c.item)
+	if err != nil {
+		return 0, err
+	}
+	return c.start.Sub(scheduled), nil
+}
@@ -0,0 +1,122 @@
+package reject
+
+import (
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// DefaultLoadReply is the reply used for invites over the meeting load caps
+// unless one is configured.
+const DefaultLoadReply = "Automatic decline - my calendar is already full " +
+	"of meetings around then." + slotsSuffix
+
+// dayOf returns the calendar day t falls on, in loc.
+func dayOf(t time.Time, loc *time.Location) (start, end time.Time) {
+	y, m, d := t.In(loc).Date()
+	start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	return start, time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+}
+
+// weekOf returns the Monday to Sunday week t falls on, in loc.
+func weekOf(t time.Time, loc *time.Location) (start, end time.Time) {
+	day, _ := dayOf(t, loc)
+	offset := (int(day.Weekday()) + 6) % 7
+	start = time.Date(day.Year(), day.Month(), day.Day()-offset, 0, 0, 0, 0, loc)
+	return start, time.Date(start.Year(), start.Month(), start.Day()+7,
+		0, 0, 0, 0, loc)
+}
+
+// loadWindow is the span of the calendar the load caps need to see to judge
+// c.
+func (r *rejecter) loadWindow(c candidate, loc *time.Location) (
+	start, end time.Time, ok bool) {
+	switch {
+	case r.opts.WeeklyLoad > 0:
+		start, end = weekOf(c.start, loc)
+	case r.opts.DailyLoad > 0:
+		start, end = dayOf(c.start, loc)
+	default:
+		return start, end, false
+	}
+	return start, end, r.opts.OverLoad.Enabled
+}
+
+// countsTowardLoad returns whether e is a meeting the user is going to.
+// Blockers, all-day events and events without attendees don't count.
+func (r *rejecter) countsTowardLoad(e *calendar.Event) bool {
+	self := selfAttendee(e)
+	if e.Status == "cancelled" || self == nil {
+		return false
+	}
+	if e.Start == nil || e.Start.DateTime == "" {
+		return false
+	}
+	switch self.ResponseStatus {
+	case "accepted", "tentative":
+	default:
+		return false
+	}
+	return r.opts.Rules.Matches(r.calId, e) == nil
+}
+
+// load returns how much of [start, end) would be spent in meetings if c
+// were accepted.
+func (r *rejecter) load(c candidate, loc *time.Location, events []*calendar.Event,
+	start, end time.Time) (time.Duration, error) {
+	busy := []timeRange{{start: c.start, end: c.end}}
+	for _, e := range events {
+		if e.Id == c.item.Id || !r.countsTowardLoad(e) {
+			continue
+		}
+		eventStart, eventEnd, err := eventSpan(e, loc)
+		if err != nil {
+			return 0, err
+		}
+		busy = append(busy, timeRange{start: eventStart, end: eventEnd})
+	}
+	var total time.Duration
+	for _, span := range mergeRanges(busy) {
+		if span.start.Before(start) {
+			span.start = start
+		}
+		if span.end.After(end) {
+			span.end = end
+		}
+		if span.end.After(span.start) {
+			total += span.end.Sub(span.start)
+		}
+	}
+	return total, nil
+}
+
+// overLoad returns why accepting c would go over a load cap, or "" if it
+// wouldn't. All-day invites don't count toward the load, like all-day
+// events.
+func (r *rejecter) overLoad(c candidate, loc *time.Location,
+	events []*calendar.Event) (string, error) {
+	if c.allDay() {
+		return "", nil
+	}
+	if r.opts.DailyLoad > 0 {
+		start, end := dayOf(c.start, loc)
+		load, err := r.load(c, loc, events, start, end)
+		if err != nil {
+			return "", err
+		}
+		if load > r.opts.DailyLoad {
+			return "over the daily meeting load", nil
+		}
+	}
+	if r.opts.WeeklyLoad > 0 {
+		start, end := weekOf(c.start, loc)
+		load, err := r.load(c, loc, events, start, end)
+		if err != nil {
+			return "", err
+		}
+		if load > r.opts.WeeklyLoad {
+			return "over the weekly meeting load", nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,173 @@
+package reject
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// EventTimes is when an invite is scheduled, and when that last changed.
+type EventTimes struct {
+	Start, End time.Time
+	// Changed is when the invite was created or last moved.
+	Changed time.Time
+}
+
+// TimeStore remembers when invites are scheduled, so an invite that was
+// moved can be told apart from one that was just edited.
+type TimeStore interface {
+	// Get returns the stored times of the given invites, by id. Unknown
+	// invites are left out.
+	Get(ctx context.Context, calId string, eventIds []string) (
+		map[string]EventTimes, error)
+	// Put stores times by invite id.
+	Put(ctx context.Context, calId string, times map[string]EventTimes) error
+	// Delete forgets cancelled invites.
+	Delete(ctx context.Context, calId string, eventIds []string) error
+}
+
+// MovedPolicy is what happens to already accepted invites that are moved
+// into a blocker.
+type MovedPolicy string
+
+const (
+	// MovedKeep leaves accepted invites alone. It is the default.
+	MovedKeep MovedPolicy = "keep"
+	// MovedRespond treats them like pending invites.
+	MovedRespond MovedPolicy = "respond"
+	// MovedTentative marks them tentative instead of declining them.
+	MovedTentative MovedPolicy = "tentative"
+)
+
+// ParseMovedPolicy validates a MovedPolicy. The empty string is MovedKeep.
+func ParseMovedPolicy(s string) (MovedPolicy, error) {
+	switch p := MovedPolicy(s); p {
+	case "":
+		return MovedKeep, nil
+	case MovedKeep, MovedRespond, MovedTentative:
+		return p, nil
+	}
+	return "", Err.New("unknown moved invite policy %q", s)
+}
+
+// trackTimes updates the stored times of the invites on a page and notes
+// which ones moved. It returns the times to store once the page is done.
+func (r *rejecter) trackTimes(e *calendar.Events, loc *time.Location) (
+	updated map[string]EventTimes, cancelled []string, err error) {
+	r.times = map[string]EventTimes{}
+	r.moved = map[string]bool{}
+	if r.opts.Times == nil {
+		return nil, nil, nil
+	}
+
+	var ids []string
+	for _, item := range e.Items {
+		if item.Status == "cancelled" {
+			cancelled = append(cancelled, item.Id)
+			continue
+		}
+		if invitee(item) != nil {
+			ids = append(ids, item.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, cancelled, nil
+	}
+	known, err := r.opts.Times.Get(r.ctx, r.calId, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated = make(map[string]EventTimes, len(ids))
+	for _, item := range e.Items {
+		if item.Status == "cancelled" || invitee(item) == nil {
+			continue
+		}
+		start, end, err := eventSpan(item, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		times := EventTimes{Start: start, End: end}
+		if prev, ok := known[item.Id]; !ok {
+			// an invite from before its times were stored only shows up in
+			// an incremental sync once it changes, so it may have just moved
+			changed := item.Created
+			if r.incremental {
+				changed = item.Updated
+			}
+			times.Changed, err = time.Parse(time.RFC3339, changed)
+			if err != nil {
+				return nil, nil, Err.Wrap(err)
+			}
+		} else if prev.Start.Equal(start) && prev.End.Equal(end) {
+			times.Changed = prev.Changed
+		} else {
+			times.Changed, err = time.Parse(time.RFC3339, item.Updated)
+			if err != nil {
+				times.Changed = time.Now()
+			}
+			r.moved[item.Id] = true
+		}
+		r.times[item.Id] = times
+		updated[item.Id] = times
+	}
+	return updated, cancelled, nil
+}
+
+// storeTimes saves what trackTimes found, once the page has been handled.
+func (r *rejecter) storeTimes(updated map[string]EventTimes,
+	cancelled []string) error {
+	if r.opts.Times == nil || r.opts.DryRun {
+		return nil
+	}
+	if len(updated) > 0 {
+		err := r.opts.Times.Put(r.ctx, r.calId, updated)
+		if err != nil {
+			return err
+		}
+	}
+	if len(cancelled) > 0 {
+		return r.opts.Times.Delete(r.ctx, r.calId, cancelled)
+	}
+	return nil
+}
+
+// lastScheduled returns when item was created or last moved, as far as is
+// known.
+func (r *rejecter) lastScheduled(item *calendar.Event) (time.Time, error) {
+	if times, ok := r.times[item.Id]; ok {
+		return times.Changed, nil
+	}
+	t, err := time.Parse(time.RFC3339, item.Created)
+	return t, Err.Wrap(err)
+}
+
+// loadTimes fills in the stored times of invites that are not on the page
+// being synced.
+func (r *rejecter) loadTimes(items []*calendar.Event) error {
+	if r.opts.Times == nil {
+		return nil
+	}
+	var ids []string
+	for _, item := range items {
+		if _, ok := r.times[item.Id]; ok {
+			continue
+		}
+		if self := invitee(item); self != nil &&
+			self.ResponseStatus == "needsAction" {
+			ids = append(ids, item.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	known, err := r.opts.Times.Get(r.ctx, r.calId, ids)
+	if err != nil {
+		return err
+	}
+	for id, times := range known {
+		r.times[id] = times
+	}
+	return nil
+}
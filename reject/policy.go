@@ -0,0 +1,144 @@
+package reject
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Policy is a check made on invites by themselves, without blockers, and
+// what happens to invites that fail it.
+type Policy struct {
+	// Enabled turns the check on.
+	Enabled bool
+	Response
+	// Reply is the comment left on invites that fail the check. If nil,
+	// Options.Reply is used.
+	Reply *Reply
+}
+
+// reply returns the policy's reply, or fallback if it has none.
+func (p *Policy) reply(fallback *Reply) *Reply {
+	if p.Reply == nil {
+		return fallback
+	}
+	return p.Reply
+}
+
+// DefaultWorkingHoursReply is the reply used for invites outside working
+// hours unless one is configured.
+const DefaultWorkingHoursReply = "Automatic decline - this is outside my " +
+	"working hours." + slotsSuffix
+
+// violation is a policy an invite fails.
+type violation struct {
+	// reason explains the failure, for Decision.Policy.
+	reason string
+	policy *Policy
+}
+
+// violation returns the strongest policy c fails, or nil if it passes all of
+// them. events is the calendar window fetched around c.
+func (r *rejecter) violation(c candidate, loc *time.Location,
+	events []*calendar.Event) (*violation, error) {
+	var worst *violation
+	consider := func(reason string, p *Policy) {
+		if worst == nil ||
+			p.action().strength() > worst.policy.action().strength() {
+			worst = &violation{reason: reason, policy: p}
+		}
+	}
+	if p := &r.opts.OutsideHours; p.Enabled &&
+		!r.withinWorkingHours(c, loc) {
+		consider("outside working hours", p)
+	}
+	if p := &r.opts.LargeMeeting; p.Enabled &&
+		attendeeCount(c) > r.opts.MaxAttendees {
+		consider("more than "+strconv.Itoa(r.opts.MaxAttendees)+" attendees", p)
+	}
+	if p := &r.opts.NoAgenda; p.Enabled && !hasAgenda(c.item) {
+		consider("no agenda", p)
+	}
+	if p := &r.opts.TooLong; p.Enabled && r.tooLong(c) {
+		consider("longer than "+formatDuration(r.opts.MaxDuration), p)
+	}
+	if p := &r.opts.NoConference; p.Enabled && !hasConference(c.item) {
+		consider("no video call or location", p)
+	}
+	if r.opts.ShortNotice.Enabled || r.opts.FarFuture.Enabled {
+		lead, err := r.leadTime(c)
+		if err != nil {
+			return nil, err
+		}
+		if p := &r.opts.ShortNotice; p.Enabled && lead < r.opts.MinLeadTime {
+			consider("sent less than "+formatDuration(r.opts.MinLeadTime)+
+				" ahead", p)
+		}
+		if p := &r.opts.FarFuture; p.Enabled && lead > r.opts.MaxLeadTime {
+			consider("sent more than "+formatDays(r.opts.MaxLeadTime)+
+				" ahead", p)
+		}
+	}
+	if p := &r.opts.OverLoad; p.Enabled {
+		reason, err := r.overLoad(c, loc, events)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			consider(reason, p)
+		}
+	}
+	if p := &r.opts.Floating; p.Enabled {
+		block, err := r.takesFloatingBlock(c, loc, events)
+		if err != nil {
+			return nil, err
+		}
+		if block != nil {
+			consider("would take the last "+block.String(), p)
+		}
+	}
+	if p := &r.opts.BackToBack; p.Enabled {
+		long, err := r.backToBack(c, loc, events)
+		if err != nil {
+			return nil, err
+		}
+		if long {
+			consider("more than "+formatDuration(r.opts.BackToBackLimit)+
+				" of back-to-back meetings", p)
+		}
+	}
+	return worst, nil
+}
+
+// withinWorkingHours returns whether c lies entirely within working hours,
+// in the calendar's time zone. All-day invites are never outside them.
+func (r *rejecter) withinWorkingHours(c candidate, loc *time.Location) bool {
+	if c.allDay() {
+		return true
+	}
+	hours := r.workingHours()
+	start, end := c.start.In(loc), c.end.In(loc)
+	var spans []timeRange
+	for _, span := range hours.workingSpans(start) {
+		spans = append(spans, timeRange{start: span[0], end: span[1]})
+	}
+	for _, span := range mergeRanges(spans) {
+		if !start.Before(span.start) && !end.After(span.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// workingHours is WorkingHours, or the default if it isn't set.
+func (r *rejecter) workingHours() Schedule {
+	if r.opts.WorkingHours.IsZero() {
+		hours, err := ParseSchedule(DefaultWorkingHours)
+		if err != nil {
+			panic(err)
+		}
+		return hours
+	}
+	return r.opts.WorkingHours
+}
@@ -14,8 +14,15 @@ import (
 
 var Err = errors.NewClass("error")
 
-func parseTime(eventTime *calendar.EventDateTime, isStart bool) (time.Time, error) {
-	loc := time.UTC
+// parseTime parses a Google Calendar date or datetime. All-day dates are
+// midnight in the event's time zone if it has one, otherwise in loc, the
+// calendar's time zone. All-day end dates are exclusive, so midnight of the end
+// date is exactly the end of the event.
+func parseTime(eventTime *calendar.EventDateTime, loc *time.Location) (
+	time.Time, error) {
+	if eventTime == nil {
+		return time.Time{}, Err.New("no event time")
+	}
 	if eventTime.TimeZone != "" {
 		var err error
 		loc, err = time.LoadLocation(eventTime.TimeZone)
@@ -30,19 +37,174 @@ func parseTime(eventTime *calendar.EventDateTime, isStart bool) (time.Time, erro
 	if eventTime.Date == "" {
 		return time.Time{}, Err.Wrap(fmt.Errorf("no datetime or date"))
 	}
-	if isStart {
-		rv, err := time.ParseInLocation("2006-01-02T15:04:05", eventTime.Date+"T00:00:00", loc)
-		return rv, Err.Wrap(err)
-	}
-	rv, err := time.ParseInLocation("2006-01-02T15:04:05", eventTime.Date+"T23:59:59", loc)
+	rv, err := time.ParseInLocation("2006-01-02", eventTime.Date, loc)
 	return rv, Err.Wrap(err)
 }
 
+func eventSpan(e *calendar.Event, loc *time.Location) (
+	start, end time.Time, err error) {
+	start, err = parseTime(e.Start, loc)
+	if err != nil {
+		return start, end, err
+	}
+	end, err = parseTime(e.End, loc)
+	return start, end, err
+}
+
+// overlaps returns whether the half-open intervals [aStart, aEnd) and
+// [bStart, bEnd) share any time.
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+func calendarLocation(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timeZone)
+	return loc, Err.Wrap(err)
+}
+
+// Options configures RejectBadInvites.
+type Options struct {
+	Rules *RuleSet
+	// Reply is the comment left on invites that are responded to.
+	Reply *Reply
+	// OldestCreation is the cutoff before which invites are left alone. With
+	// Times set, it applies to when an invite was last moved rather than
+	// when it was created.
+	OldestCreation time.Time
+	// Times, if not nil, keeps track of when invites are scheduled, so
+	// invites moved into a blocker are noticed.
+	Times TimeStore
+	// MovedAccepted is what happens to accepted invites moved into a
+	// blocker. It needs Times.
+	MovedAccepted MovedPolicy
+	// InternalDomains are the organizer domains that rules with ExternalOnly
+	// let through. If empty, the user's own domain is used.
+	InternalDomains AddressList
+	// MaxAttendees, if not zero, is the most people an invite can have
+	// before LargeMeeting applies to it.
+	MaxAttendees int
+	LargeMeeting Policy
+	// Optional, if enabled, replaces the response and reply for invites the
+	// user is an optional attendee of, though its action is capped at the
+	// one they would get otherwise. Rules can override its response.
+	Optional Policy
+	// Keywords are title and description patterns whose invites
+	// KeywordDeny applies to, whatever the blockers are.
+	Keywords    KeywordList
+	KeywordDeny Policy
+	// Allow lists organizers whose invites are never responded to, and Deny
+	// organizers whose invites are always declined. Both are consulted
+	// before looking for conflicts, Allow first.
+	Allow AddressList
+	Deny  AddressList
+	// SuggestSlots is how many of the invitee's next free slots of the same
+	// length to offer in decline comments.
+	SuggestSlots int
+	// WorkingHours bounds the suggested slots. If it is zero,
+	// DefaultWorkingHours is used.
+	WorkingHours Schedule
+	// OutsideHours applies to invites that are not entirely within
+	// WorkingHours.
+	OutsideHours Policy
+	// DailyLoad and WeeklyLoad cap the time spent in accepted and tentative
+	// meetings per day and per Monday to Sunday week, if not zero. OverLoad
+	// applies to invites that would go over either.
+	DailyLoad  time.Duration
+	WeeklyLoad time.Duration
+	OverLoad   Policy
+	// FloatingBlocks keep some free time within a window, without a fixed
+	// blocker. Floating applies to invites that would take a block's last
+	// free slot.
+	FloatingBlocks []FloatingBlock
+	Floating       Policy
+	// BackToBackLimit is the longest run of back-to-back meetings, where
+	// gaps shorter than BackToBackGap don't break a run. BackToBack applies
+	// to invites that would make a longer one.
+	BackToBackLimit time.Duration
+	BackToBackGap   time.Duration
+	BackToBack      Policy
+	// ShortNotice applies to invites that start less than MinLeadTime after
+	// they were sent or last moved, and FarFuture to ones that start more
+	// than MaxLeadTime after.
+	MinLeadTime time.Duration
+	ShortNotice Policy
+	MaxLeadTime time.Duration
+	FarFuture   Policy
+	// NoAgenda applies to invites with an empty description, TooLong to
+	// ones longer than MaxDuration and NoConference to ones with neither
+	// conference data nor a location.
+	NoAgenda     Policy
+	MaxDuration  time.Duration
+	TooLong      Policy
+	NoConference Policy
+	// Log, if not nil, keeps every decision acted on, including which
+	// blocker caused each response, so responses can be withdrawn when
+	// their blocker is removed or moved.
+	Log DecisionLog
+	// RestoreStatus is the response given to invites whose decline is
+	// withdrawn: "needsAction" (the default) or "accepted".
+	RestoreStatus string
+	// RestoreNotify tells the organizer when a decline is withdrawn, with
+	// RestoreReply as the comment.
+	RestoreNotify bool
+	RestoreReply  *Reply
+	// DryRun runs the full sync and conflict logic but does not respond to
+	// any invites. The returned decisions describe what would have happened.
+	DryRun bool
+	// Upcoming, if not zero, limits a sync from an empty token to events in
+	// the next Upcoming, with no sync token to resume from. It keeps
+	// previews from going through the whole calendar.
+	Upcoming time.Duration
+}
+
+// PreviewHorizon is how far ahead dry runs look for pending invites, as
+// their Upcoming.
+const PreviewHorizon = 4 * 7 * 24 * time.Hour
+
+// blockerPadding is how far around the candidate invites blockers are
+// fetched.
+const blockerPadding = 25 * time.Hour
+
+type rejecter struct {
+	ctx       context.Context
+	srv       *calendar.Service
+	calId     string
+	opts      Options
+	decisions []*Decision
+	// incremental is set when syncing from a sync token, so only changed
+	// events are listed.
+	incremental bool
+	// times and moved are what is known about the current page's invites.
+	times map[string]EventTimes
+	moved map[string]bool
+	// series maps the recurring invites considered so far to whether the
+	// whole series was responded to.
+	series map[string]bool
+}
+
+type candidate struct {
+	item       *calendar.Event
+	start, end time.Time
+	// accepted is set for accepted invites that were moved.
+	accepted bool
+}
+
+// allDay returns whether c is an all-day invite.
+func (c candidate) allDay() bool {
+	return c.item.Start != nil && c.item.Start.Date != ""
+}
+
+// RejectBadInvites syncs calId from lastToken and responds to new invites
+// that conflict with blockers. It returns the decisions it made. The
+// syncTokenPersister, if not nil, is called with the token to resume from as
+// the sync progresses.
 func RejectBadInvites(ctx context.Context, srv *calendar.Service,
-	calId, lastToken string, autorejectMatcher func(e *calendar.Event) bool,
-	autorejectComment string, oldestCreation time.Time,
+	calId, lastToken string, opts Options,
 	syncTokenPersister func(ctx context.Context, nextToken string) error) (
-	err error) {
+	decisions []*Decision, err error) {
 
 	var lastPageToken, lastSyncToken string
 	if strings.HasPrefix(lastToken, "pagesync:") {
@@ -61,85 +223,16 @@ func RejectBadInvites(ctx context.Context, srv *calendar.Service,
 		lastSyncToken = lastToken
 	}
 
-	callback := func(e *calendar.Events) error {
-		for _, item := range e.Items {
-			if len(item.Attendees) != 1 {
-				continue
-			}
-			if item.Attendees[0].ResponseStatus != "needsAction" {
-				continue
-			}
-			createdTime, err := time.Parse(time.RFC3339, item.Created)
-			if err != nil {
-				return Err.Wrap(err)
-			}
-			if createdTime.Before(oldestCreation) {
-				continue
-			}
-			if item.Start.DateTime == "" || item.End.DateTime == "" {
-				continue
-			}
-
-			itemStart, err := parseTime(item.Start, true)
-			if err != nil {
-				return err
-			}
-			itemEnd, err := parseTime(item.End, false)
-			if err != nil {
-				return err
-			}
+	r := &rejecter{ctx: ctx, srv: srv, calId: calId, opts: opts,
+		incremental: lastSyncToken != "", series: map[string]bool{}}
 
-			conflictFound := false
-			err = srv.Events.List(calId).
-				SingleEvents(true).
-				MaxAttendees(1).
-				TimeMin(itemStart.Add(-time.Hour*25).Format(time.RFC3339)).
-				TimeMax(itemEnd.Add(time.Hour*25).Format(time.RFC3339)).
-				OrderBy("startTime").
-				Pages(ctx,
-					func(e *calendar.Events) error {
-						for _, conflict := range e.Items {
-							if !autorejectMatcher(conflict) {
-								continue
-							}
-							conflictStart, err := parseTime(conflict.Start, true)
-							if err != nil {
-								return err
-							}
-							conflictEnd, err := parseTime(conflict.End, false)
-							if err != nil {
-								return err
-							}
-							if conflictStart.After(itemEnd) || conflictStart.Equal(itemEnd) {
-								continue
-							}
-							if conflictEnd.Before(itemStart) || conflictEnd.Equal(itemStart) {
-								continue
-							}
-							conflictFound = true
-						}
-						return nil
-					})
-			if err != nil {
-				return Err.Wrap(err)
-			}
-			if conflictFound {
-				_, err = srv.Events.Patch(calId, item.Id, &calendar.Event{
-					Id:    item.Id,
-					Start: item.Start,
-					End:   item.End,
-					Attendees: []*calendar.EventAttendee{
-						{
-							Email:          item.Attendees[0].Email,
-							Comment:        autorejectComment,
-							Id:             item.Attendees[0].Id,
-							ResponseStatus: "declined",
-						}},
-				}).Context(ctx).SendUpdates("all").Do()
-				if err != nil {
-					return Err.Wrap(err)
-				}
-			}
+	callback := func(e *calendar.Events) error {
+		err := r.page(e)
+		if err != nil {
+			return err
+		}
+		if syncTokenPersister == nil {
+			return nil
 		}
 		if e.NextSyncToken != "" {
 			err = syncTokenPersister(ctx, "sync:"+e.NextSyncToken)
@@ -158,21 +251,452 @@ func RejectBadInvites(ctx context.Context, srv *calendar.Service,
 		return nil
 	}
 
-	query := srv.Events.List(calId).MaxAttendees(1).SingleEvents(true)
+	query := srv.Events.List(calId).SingleEvents(true)
+	if opts.Rules.wantsWorkingLocations() {
+		query.EventTypes(allEventTypes...)
+	}
 	if lastSyncToken != "" {
 		query.SyncToken(lastSyncToken)
 	}
 	if lastPageToken != "" {
 		query.PageToken(lastPageToken)
 	}
+	if opts.Upcoming > 0 && lastToken == "" {
+		now := time.Now()
+		query.TimeMin(now.Format(time.RFC3339)).
+			TimeMax(now.Add(opts.Upcoming).Format(time.RFC3339))
+	}
 	err = query.Pages(ctx, callback)
 	if err != nil {
 		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusGone {
-			return RejectBadInvites(
-				ctx, srv, calId, "", autorejectMatcher, autorejectComment, oldestCreation,
-				syncTokenPersister)
+			return RejectBadInvites(ctx, srv, calId, "", opts, syncTokenPersister)
+		}
+		return r.decisions, Err.Wrap(err)
+	}
+	return r.decisions, nil
+}
+
+// candidate returns item as a candidate if it is a pending invite that is
+// new enough to respond to, or an accepted one that was moved and the
+// MovedAccepted policy covers.
+func (r *rejecter) candidate(item *calendar.Event, loc *time.Location) (
+	c candidate, ok bool, err error) {
+	self := invitee(item)
+	if self == nil {
+		return c, false, nil
+	}
+	switch self.ResponseStatus {
+	case "needsAction":
+	case "accepted":
+		if !r.moved[item.Id] || r.opts.MovedAccepted == "" ||
+			r.opts.MovedAccepted == MovedKeep {
+			return c, false, nil
+		}
+		c.accepted = true
+	default:
+		return c, false, nil
+	}
+	scheduled, err := r.lastScheduled(item)
+	if err != nil {
+		return c, false, err
+	}
+	if scheduled.Before(r.opts.OldestCreation) {
+		return c, false, nil
+	}
+
+	itemStart, itemEnd, err := eventSpan(item, loc)
+	if err != nil {
+		return c, false, err
+	}
+	if itemEnd.Before(time.Now()) {
+		// nothing useful to say about a meeting that already happened
+		return c, false, nil
+	}
+	c.item, c.start, c.end = item, itemStart, itemEnd
+	return c, true, nil
+}
+
+// page handles one page of sync results. The blockers for every candidate
+// invite on the page are fetched with a single window query. New or moved
+// blockers on the page make the pending invites they now cover candidates
+// too.
+func (r *rejecter) page(e *calendar.Events) error {
+	loc, err := calendarLocation(e.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	var candidates []candidate
+	var changedBlockers []timeRange
+	var windowStart, windowEnd time.Time
+	haveWindow := false
+	extendWindow := func(start, end time.Time) {
+		if !haveWindow || start.Before(windowStart) {
+			windowStart = start
+		}
+		if !haveWindow || end.After(windowEnd) {
+			windowEnd = end
+		}
+		haveWindow = true
+	}
+
+	updatedTimes, cancelled, err := r.trackTimes(e, loc)
+	if err != nil {
+		return err
+	}
+
+	restores, err := r.restoreCandidates(e, loc)
+	if err != nil {
+		return err
+	}
+	for _, restore := range restores {
+		extendWindow(restore.start, restore.end)
+	}
+
+	for _, item := range e.Items {
+		if item.Status == "cancelled" {
+			continue
+		}
+		if rule := r.opts.Rules.Matches(r.calId, item); rule != nil {
+			blockerStart, blockerEnd, err := eventSpan(item, loc)
+			if err != nil {
+				return err
+			}
+			blockerStart, blockerEnd = rule.pad(blockerStart, blockerEnd)
+			if blockerEnd.Before(time.Now()) {
+				continue
+			}
+			extendWindow(blockerStart, blockerEnd)
+			changedBlockers = append(changedBlockers,
+				timeRange{start: blockerStart, end: blockerEnd})
+		}
+
+		c, ok, err := r.candidate(item, loc)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
 		}
+		extendWindow(c.start, c.end)
+		if start, end, ok := r.loadWindow(c, loc); ok {
+			// invites only found once the window is fetched may not get
+			// their whole week, so their load can come out low
+			extendWindow(start, end)
+		}
+		candidates = append(candidates, c)
+	}
+	if !haveWindow {
+		return r.storeTimes(updatedTimes, cancelled)
+	}
+
+	padding := blockerPadding + r.opts.Rules.buffer()
+	w, err := r.fetchWindow(loc,
+		windowStart.Add(-padding), windowEnd.Add(padding))
+	if err != nil {
+		return err
+	}
+
+	if len(changedBlockers) > 0 {
+		err = r.loadTimes(w.events)
+		if err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		for _, c := range candidates {
+			seen[c.item.Id] = true
+		}
+		for _, item := range w.events {
+			if seen[item.Id] {
+				continue
+			}
+			c, ok, err := r.candidate(item, loc)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			for _, blocker := range changedBlockers {
+				if overlaps(c.start, c.end, blocker.start, blocker.end) {
+					seen[item.Id] = true
+					candidates = append(candidates, c)
+					break
+				}
+			}
+		}
+	}
+
+	for _, restore := range restores {
+		err = r.reconsider(restore, loc, w.blockers)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range candidates {
+		err = r.judge(c, loc, w)
+		if err != nil {
+			return err
+		}
+	}
+	return r.storeTimes(updatedTimes, cancelled)
+}
+
+// conflict returns the blocker that decides what happens to c, or nil if
+// none do.
+func (r *rejecter) conflict(c candidate, blockers *intervalIndex) *interval {
+	var conflict *interval
+	for _, blocker := range blockers.overlapping(c.start, c.end) {
+		if blocker.event.Id == c.item.Id {
+			continue
+		}
+		if !blocker.rule.enoughOverlap(c.start, c.end, blocker.start, blocker.end) {
+			continue
+		}
+		if blocker.rule.ExternalOnly && r.internal(c) {
+			continue
+		}
+		if conflict == nil ||
+			blocker.resp.action().strength() > conflict.resp.action().strength() {
+			blocker := blocker
+			conflict = &blocker
+		}
+	}
+	return conflict
+}
+
+// judge decides what to do with a candidate invite and does it.
+func (r *rejecter) judge(c candidate, loc *time.Location, w *window) error {
+	blockers := w.blockers
+	if r.series[c.item.RecurringEventId] {
+		// the response to the whole series covers this instance
+		return nil
+	}
+
+	if entry := r.opts.Allow.Match(organizerEmail(c.item)); entry != "" {
+		d := r.decision(c, ActionSkip)
+		d.ListEntry = entry
+		r.decisions = append(r.decisions, d)
+		return r.record(d)
+	}
+
+	if entry := r.opts.Deny.Match(organizerEmail(c.item)); entry != "" {
+		d := r.decision(c, ActionDecline)
+		d.ListEntry = entry
+		return r.respond(c, loc, blockers, d, Response{}, r.opts.Reply, nil)
+	}
+
+	if p := &r.opts.KeywordDeny; p.Enabled {
+		if entry := r.opts.Keywords.Match(c.item); entry != "" {
+			d := r.decision(c, p.action())
+			d.Keyword = entry
+			return r.respond(c, loc, blockers, d, p.Response,
+				p.reply(r.opts.Reply), nil)
+		}
+	}
+
+	conflict := r.conflict(c, blockers)
+	v, err := r.violation(c, loc, w.events)
+	if err != nil {
+		return err
+	}
+	if v != nil && (conflict == nil ||
+		v.policy.action().strength() > conflict.resp.action().strength()) {
+		d := r.decision(c, v.policy.action())
+		d.Policy = v.reason
+		return r.respond(c, loc, blockers, d, v.policy.Response,
+			v.policy.reply(r.opts.Reply), nil)
+	}
+	if conflict == nil {
+		return nil
+	}
+	series, ok, err := r.seriesCandidate(c, loc, conflict.rule)
+	if err != nil {
+		return err
+	}
+	if ok {
+		c = series
+		r.series[c.item.Id] = true
+	}
+	d := r.decision(c, conflict.resp.action())
+	d.Series = ok
+	d.BlockerId = conflict.event.Id
+	d.Blocker = conflict.event.Summary
+	d.Rule = conflict.rule.Name
+	return r.respond(c, loc, blockers, d, conflict.resp, r.opts.Reply,
+		conflict)
+}
+
+// window is what is on the calendar around a page's candidates.
+type window struct {
+	blockers *intervalIndex
+	events   []*calendar.Event
+}
+
+// fetchWindow lists the events between min and max and indexes the ones
+// the rules consider blockers.
+func (r *rejecter) fetchWindow(loc *time.Location, min, max time.Time) (
+	*window, error) {
+	var w window
+	var blockers []interval
+	query := r.srv.Events.List(r.calId).
+		SingleEvents(true).
+		TimeMin(min.Format(time.RFC3339)).
+		TimeMax(max.Format(time.RFC3339)).
+		OrderBy("startTime")
+	if r.opts.Rules.wantsWorkingLocations() {
+		query.EventTypes(allEventTypes...)
+	}
+	err := query.Pages(r.ctx,
+		func(e *calendar.Events) error {
+			for _, event := range e.Items {
+				w.events = append(w.events, event)
+				rule := r.opts.Rules.Matches(r.calId, event)
+				if rule == nil {
+					continue
+				}
+				blockerStart, blockerEnd, err := eventSpan(event, loc)
+				if err != nil {
+					return err
+				}
+				blockerStart, blockerEnd = rule.pad(blockerStart, blockerEnd)
+				blockers = append(blockers, interval{
+					start: blockerStart, end: blockerEnd,
+					event: event, rule: rule,
+					resp: blockerResponse(event, rule)})
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, Err.Wrap(err)
+	}
+	w.blockers = newIntervalIndex(blockers)
+	return &w, nil
+}
+
+// capAction keeps moved accepted invites from being declined if the
+// MovedAccepted policy says so.
+func (r *rejecter) capAction(c candidate, action Action) Action {
+	if c.accepted && r.opts.MovedAccepted == MovedTentative &&
+		action == ActionDecline {
+		return ActionTentative
+	}
+	return action
+}
+
+func (r *rejecter) decision(c candidate, action Action) *Decision {
+	action = r.capAction(c, action)
+	return &Decision{
+		Time:      time.Now(),
+		CalId:     r.calId,
+		InviteId:  c.item.Id,
+		Summary:   c.item.Summary,
+		Organizer: organizerEmail(c.item),
+		Start:     c.start,
+		End:       c.end,
+		Action:    action,
+		DryRun:    r.opts.DryRun,
+	}
+}
+
+// respond records decision and acts on it with resp, commenting with reply.
+// conflict is the blocker responsible, if there is one.
+func (r *rejecter) respond(c candidate, loc *time.Location,
+	blockers *intervalIndex, decision *Decision, resp Response, reply *Reply,
+	conflict *interval) error {
+	if self := selfAttendee(c.item); self.Optional && r.opts.Optional.Enabled {
+		optional := r.opts.Optional.Response
+		if conflict != nil && conflict.rule.Optional != nil {
+			optional = *conflict.rule.Optional
+		} else if optional.action().strength() > decision.Action.strength() {
+			// being optional shouldn't get an invite a stronger action, just
+			// the optional reply and notifications
+			optional.Action, optional.Color = resp.Action, resp.Color
+		}
+		resp = optional
+		reply = r.opts.Optional.reply(reply)
+		decision.Action = r.capAction(c, resp.action())
+		decision.Optional = true
+	}
+	action := decision.Action
+	r.decisions = append(r.decisions, decision)
+
+	orgLoc := organizerLocation(c.item, loc)
+	data := &ReplyData{
+		OrganizerEmail: organizerEmail(c.item),
+		Title:          c.item.Summary,
+		Start:          c.start.In(orgLoc),
+		End:            c.end.In(orgLoc),
+	}
+	data.Reason = decision.Policy
+	if conflict != nil {
+		data.Blocker = conflict.event.Summary
+		data.BlockerEnd = conflict.end.In(orgLoc)
+	}
+	if c.item.Organizer != nil {
+		data.OrganizerName = c.item.Organizer.DisplayName
+	}
+	if action == ActionDecline {
+		slots, err := r.suggestSlots(c, loc, blockers)
+		if err != nil {
+			return err
+		}
+		decision.Suggestions = slots
+		for _, slot := range slots {
+			data.Slots = append(data.Slots, slot.In(orgLoc))
+		}
+		if len(data.Slots) > 0 {
+			data.NextFree = &data.Slots[0]
+		}
+	}
+	comment, err := reply.Render(data)
+	if err != nil {
+		return err
+	}
+
+	if r.opts.DryRun {
+		return nil
+	}
+
+	patch := &calendar.Event{Id: c.item.Id, Start: c.item.Start, End: c.item.End}
+	notify := resp.notify()
+	switch action {
+	case ActionColor:
+		patch.ColorId = resp.Color
+		notify = "none"
+	case ActionTentative:
+		patch.Attendees = []*calendar.EventAttendee{
+			response(c, "tentative", comment)}
+	default:
+		patch.Attendees = []*calendar.EventAttendee{
+			response(c, "declined", comment)}
+	}
+	_, err = r.srv.Events.Patch(r.calId, c.item.Id, patch).
+		Context(r.ctx).SendUpdates(notify).Do()
+	if err != nil {
 		return Err.Wrap(err)
 	}
-	return nil
+	return r.record(decision)
+}
+
+// record adds decisions that were acted on to the log.
+func (r *rejecter) record(d *Decision) error {
+	if r.opts.Log == nil || r.opts.DryRun {
+		return nil
+	}
+	return r.opts.Log.Record(r.ctx, d)
+}
+
+// response is the user's own attendee entry on c with the given response.
+// Only it is sent, which leaves the other attendees alone.
+func response(c candidate, status, comment string) *calendar.EventAttendee {
+	self := selfAttendee(c.item)
+	return &calendar.EventAttendee{
+		Email:          self.Email,
+		Comment:        comment,
+		Id:             self.Id,
+		Optional:       self.Optional,
+		ResponseStatus: status,
+	}
 }
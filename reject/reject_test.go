@@ -0,0 +1,1229 @@
+package reject
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func date(d string) *calendar.EventDateTime {
+	return &calendar.EventDateTime{Date: d}
+}
+
+func dateTime(dt string) *calendar.EventDateTime {
+	return &calendar.EventDateTime{DateTime: dt}
+}
+
+func TestParseTime(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		calendar string
+		time     *calendar.EventDateTime
+		want     string
+	}{
+		{"datetime", "America/New_York",
+			dateTime("2026-03-02T10:00:00-05:00"), "2026-03-02T15:00:00Z"},
+		{"date in utc", "", date("2026-03-02"), "2026-03-02T00:00:00Z"},
+		{"date in calendar zone", "America/Los_Angeles",
+			date("2026-03-02"), "2026-03-02T08:00:00Z"},
+		{"date in event zone", "America/Los_Angeles",
+			&calendar.EventDateTime{Date: "2026-03-02", TimeZone: "Asia/Tokyo"},
+			"2026-03-01T15:00:00Z"},
+		{"date before spring forward", "America/New_York",
+			date("2026-03-08"), "2026-03-08T05:00:00Z"},
+		{"date after spring forward", "America/New_York",
+			date("2026-03-09"), "2026-03-09T04:00:00Z"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			loc, err := calendarLocation(test.calendar)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseTime(test.time, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := time.Parse(time.RFC3339, test.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Fatalf("got %v, want %v", got.UTC(), want)
+			}
+		})
+	}
+}
+
+func TestAllDayConflicts(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		calendar   string
+		inviteFrom *calendar.EventDateTime
+		inviteTo   *calendar.EventDateTime
+		blockFrom  *calendar.EventDateTime
+		blockTo    *calendar.EventDateTime
+		want       bool
+	}{
+		{"timed invite during all-day blocker", "America/New_York",
+			dateTime("2026-03-02T10:00:00-05:00"),
+			dateTime("2026-03-02T11:00:00-05:00"),
+			date("2026-03-02"), date("2026-03-03"), true},
+		{"timed invite on exclusive end date", "America/New_York",
+			dateTime("2026-03-03T09:00:00-05:00"),
+			dateTime("2026-03-03T10:00:00-05:00"),
+			date("2026-03-02"), date("2026-03-03"), false},
+		{"timed invite starting at exclusive end", "America/New_York",
+			dateTime("2026-03-03T00:00:00-05:00"),
+			dateTime("2026-03-03T01:00:00-05:00"),
+			date("2026-03-02"), date("2026-03-03"), false},
+		{"timed invite ending at blocker start", "America/New_York",
+			dateTime("2026-03-01T23:00:00-05:00"),
+			dateTime("2026-03-02T00:00:00-05:00"),
+			date("2026-03-02"), date("2026-03-03"), false},
+		{"blocker day follows calendar zone", "America/Los_Angeles",
+			dateTime("2026-03-03T05:00:00Z"),
+			dateTime("2026-03-03T06:00:00Z"),
+			date("2026-03-02"), date("2026-03-03"), true},
+		{"blocker day does not follow utc", "America/Los_Angeles",
+			dateTime("2026-03-02T07:00:00Z"),
+			dateTime("2026-03-02T08:00:00Z"),
+			date("2026-03-02"), date("2026-03-03"), false},
+		{"multi-day blocker", "Europe/Berlin",
+			dateTime("2026-03-04T12:00:00+01:00"),
+			dateTime("2026-03-04T13:00:00+01:00"),
+			date("2026-03-02"), date("2026-03-05"), true},
+		{"late on spring forward day", "America/New_York",
+			dateTime("2026-03-08T23:00:00-04:00"),
+			dateTime("2026-03-08T23:30:00-04:00"),
+			date("2026-03-08"), date("2026-03-09"), true},
+		{"just after spring forward day", "America/New_York",
+			dateTime("2026-03-09T00:00:00-04:00"),
+			dateTime("2026-03-09T00:30:00-04:00"),
+			date("2026-03-08"), date("2026-03-09"), false},
+		{"just before spring forward day", "America/New_York",
+			dateTime("2026-03-07T23:30:00-05:00"),
+			dateTime("2026-03-08T00:00:00-05:00"),
+			date("2026-03-08"), date("2026-03-09"), false},
+		{"extra hour of fall back day", "America/New_York",
+			dateTime("2026-11-01T23:30:00-05:00"),
+			dateTime("2026-11-01T23:45:00-05:00"),
+			date("2026-11-01"), date("2026-11-02"), true},
+		{"just after fall back day", "America/New_York",
+			dateTime("2026-11-02T00:00:00-05:00"),
+			dateTime("2026-11-02T00:30:00-05:00"),
+			date("2026-11-01"), date("2026-11-02"), false},
+		{"all-day invite during timed blocker", "America/New_York",
+			date("2026-03-02"), date("2026-03-03"),
+			dateTime("2026-03-02T10:00:00-05:00"),
+			dateTime("2026-03-02T11:00:00-05:00"), true},
+		{"all-day invite after timed blocker", "America/New_York",
+			date("2026-03-03"), date("2026-03-04"),
+			dateTime("2026-03-02T22:00:00-05:00"),
+			dateTime("2026-03-03T00:00:00-05:00"), false},
+		{"all-day invite during all-day blocker", "America/New_York",
+			date("2026-03-02"), date("2026-03-04"),
+			date("2026-03-03"), date("2026-03-04"), true},
+		{"all-day invite after all-day blocker", "America/New_York",
+			date("2026-03-03"), date("2026-03-04"),
+			date("2026-03-02"), date("2026-03-03"), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			loc, err := calendarLocation(test.calendar)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inviteStart, inviteEnd, err := eventSpan(&calendar.Event{
+				Start: test.inviteFrom, End: test.inviteTo}, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			blockStart, blockEnd, err := eventSpan(&calendar.Event{
+				Start: test.blockFrom, End: test.blockTo}, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := overlaps(inviteStart, inviteEnd, blockStart, blockEnd)
+			if got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// busyCalendar returns a calendar with a lunch blocker every day and invites
+// spread every few hours around it.
+func busyCalendar(invites int) *fakeCalendar {
+	f := &fakeCalendar{timeZone: "America/New_York"}
+	base := time.Now().UTC().Truncate(24 * time.Hour).Add(48 * time.Hour)
+	for day := 0; day <= invites/8; day++ {
+		dayStart := base.Add(time.Duration(day) * 24 * time.Hour)
+		f.addBlocker(fmt.Sprintf("lunch-%d", day),
+			dayStart.Add(12*time.Hour), dayStart.Add(13*time.Hour))
+	}
+	for i := 0; i < invites; i++ {
+		start := base.Add(time.Duration(i)*3*time.Hour + 30*time.Minute)
+		f.addInvite(fmt.Sprintf("invite-%d", i), base.Add(-time.Hour),
+			start, start.Add(90*time.Minute))
+	}
+	return f
+}
+
+func TestBatchedConflicts(t *testing.T) {
+	f := busyCalendar(40)
+	loc, err := calendarLocation(f.timeZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the answer from checking every invite against every blocker
+	want := map[string]string{}
+	for _, invite := range f.events {
+		if len(invite.Attendees) == 0 {
+			continue
+		}
+		inviteStart, inviteEnd, err := eventSpan(invite, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, blocker := range f.events {
+			if len(blocker.Attendees) != 0 {
+				continue
+			}
+			blockerStart, blockerEnd, err := eventSpan(blocker, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if overlaps(inviteStart, inviteEnd, blockerStart, blockerEnd) {
+				want[invite.Id] = blocker.Id
+				break
+			}
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("test calendar has no conflicts")
+	}
+
+	decisions, err := RejectBadInvites(context.Background(), f.service(t),
+		"primary", "", Options{Rules: DefaultRuleSet("(autoreject)")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, d := range decisions {
+		got[d.InviteId] = d.BlockerId
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if f.patches != len(want) {
+		t.Fatalf("got %d patches, want %d", f.patches, len(want))
+	}
+	// one sync list and one blocker list for the single page
+	if f.lists != 2 {
+		t.Fatalf("got %d list calls, want 2", f.lists)
+	}
+}
+
+func BenchmarkSyncPage(b *testing.B) {
+	const invites = 100
+	f := busyCalendar(invites)
+	srv := f.service(b)
+	opts := Options{Rules: DefaultRuleSet("(autoreject)"), DryRun: true}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := RejectBadInvites(context.Background(), srv, "primary", "",
+			opts, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	// one sync list and one blocker list, however many invites there are
+	if f.lists != 2*b.N {
+		b.Fatalf("got %d list calls for %d syncs, want %d", f.lists, b.N, 2*b.N)
+	}
+	b.ReportMetric(float64(f.lists)/float64(b.N), "lists/op")
+}
+
+func TestRuleSetMatches(t *testing.T) {
+	blocker := &calendar.Event{Summary: "Focus (autoreject)",
+		Description: "deep work", ColorId: "5"}
+	meeting := &calendar.Event{Summary: "Focus (autoreject)",
+		Attendees: []*calendar.EventAttendee{{Email: "a@example.com"}}}
+	free := &calendar.Event{Summary: "Lunch", Transparency: "transparent"}
+	for _, test := range []struct {
+		name  string
+		rules string
+		calId string
+		event *calendar.Event
+		want  string
+	}{
+		{"summary contains", `{"rules": [{"name": "a",
+			"summary_contains": "(AUTOREJECT)"}]}`, "", blocker, "a"},
+		{"attendees aren't blockers", `{"rules": [{"name": "a",
+			"summary_contains": "autoreject"}]}`, "", meeting, ""},
+		{"unless allowed", `{"rules": [{"name": "a",
+			"summary_contains": "autoreject", "allow_attendees": true}]}`,
+			"", meeting, "a"},
+		{"summary regexp", `{"rules": [{"name": "a",
+			"summary_regexp": "^focus\\b"}]}`, "", blocker, "a"},
+		{"summary regexp mismatch", `{"rules": [{"name": "a",
+			"summary_regexp": "^autoreject"}]}`, "", blocker, ""},
+		{"description regexp", `{"rules": [{"name": "a",
+			"description_regexp": "deep|shallow"}]}`, "", blocker, "a"},
+		{"every field has to match", `{"rules": [{"name": "a",
+			"summary_contains": "autoreject", "color_id": "6"}]}`,
+			"", blocker, ""},
+		{"default transparency is opaque", `{"rules": [{"name": "a",
+			"transparency": "opaque"}]}`, "", blocker, "a"},
+		{"transparency", `{"rules": [{"name": "a",
+			"transparency": "transparent"}]}`, "", free, "a"},
+		{"default event type", `{"rules": [{"name": "a",
+			"event_type": "default"}]}`, "", blocker, "a"},
+		{"calendar", `{"rules": [{"name": "a", "calendar": "work"}]}`,
+			"home", blocker, ""},
+		{"any picks the first match", `{"rules": [
+			{"name": "a", "color_id": "6"},
+			{"name": "b", "summary_contains": "focus"},
+			{"name": "c", "color_id": "5"}]}`, "", blocker, "b"},
+		{"any without a match", `{"match": "any", "rules": [
+			{"name": "a", "color_id": "6"},
+			{"name": "b", "summary_contains": "lunch"}]}`, "", blocker, ""},
+		{"all", `{"match": "all", "rules": [
+			{"name": "a", "color_id": "5"},
+			{"name": "b", "summary_contains": "focus"}]}`, "", blocker, "a"},
+		{"all with a mismatch", `{"match": "all", "rules": [
+			{"name": "a", "color_id": "5"},
+			{"name": "b", "summary_contains": "lunch"}]}`, "", blocker, ""},
+		{"no rules", `{"rules": []}`, "", blocker, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rs, err := ParseRuleSet([]byte(test.rules))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			if r := rs.Matches(test.calId, test.event); r != nil {
+				got = r.Name
+			}
+			if got != test.want {
+				t.Fatalf("got rule %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRuleSetErrors(t *testing.T) {
+	for _, rules := range []string{
+		`{"match": "some", "rules": []}`,
+		`{"rules": [{"summary_regexp": "("}]}`,
+		`{"rules": [{"description_regexp": "[a-"}]}`,
+		`{"rules": [`,
+	} {
+		if _, err := ParseRuleSet([]byte(rules)); err == nil {
+			t.Errorf("%s: expected an error", rules)
+		}
+	}
+}
+
+func mustTime(tb testing.TB, s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return t
+}
+
+func TestFreeSlots(t *testing.T) {
+	loc, err := calendarLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hours, err := ParseSchedule(DefaultWorkingHours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy := mergeRanges([]timeRange{
+		{mustTime(t, "2026-03-02T10:00:00-05:00"),
+			mustTime(t, "2026-03-02T11:00:00-05:00")},
+		{mustTime(t, "2026-03-02T11:00:00-05:00"),
+			mustTime(t, "2026-03-02T11:50:00-05:00")},
+		{mustTime(t, "2026-03-02T14:00:00-05:00"),
+			mustTime(t, "2026-03-02T16:40:00-05:00")},
+	})
+	for _, test := range []struct {
+		name   string
+		from   string
+		until  string
+		length time.Duration
+		n      int
+		want   []string
+	}{
+		{"aligned and one per gap", "2026-03-02T09:07:00-05:00",
+			"2026-03-16T00:00:00-04:00", 30 * time.Minute, 5, []string{
+				"2026-03-02T09:15:00-05:00",
+				"2026-03-02T12:00:00-05:00",
+				"2026-03-03T09:00:00-05:00",
+				"2026-03-04T09:00:00-05:00",
+				"2026-03-05T09:00:00-05:00"}},
+		{"at most n", "2026-03-02T09:07:00-05:00",
+			"2026-03-16T00:00:00-04:00", 30 * time.Minute, 2, []string{
+				"2026-03-02T09:15:00-05:00",
+				"2026-03-02T12:00:00-05:00"}},
+		{"long enough to end at the next busy time",
+			"2026-03-02T09:07:00-05:00", "2026-03-16T00:00:00-04:00",
+			2 * time.Hour, 2, []string{
+				"2026-03-02T12:00:00-05:00",
+				"2026-03-03T09:00:00-05:00"}},
+		{"not past until", "2026-03-02T09:07:00-05:00",
+			"2026-03-02T12:15:00-05:00", 30 * time.Minute, 5, []string{
+				"2026-03-02T09:15:00-05:00"}},
+		{"not outside working hours", "2026-03-06T16:50:00-05:00",
+			"2026-03-16T00:00:00-04:00", 30 * time.Minute, 1, []string{
+				"2026-03-09T09:00:00-04:00"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := freeSlots(busy, hours, loc, mustTime(t, test.from),
+				mustTime(t, test.until), test.length, test.n)
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if !got[i].Equal(mustTime(t, test.want[i])) {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnoughOverlap(t *testing.T) {
+	at := func(clock string) time.Time {
+		return mustTime(t, "2026-03-02T"+clock+":00-05:00")
+	}
+	for _, test := range []struct {
+		name                   string
+		rule                   Rule
+		inviteFrom, inviteTo   string
+		blockerFrom, blockerTo string
+		want                   bool
+	}{
+		{"touching", Rule{}, "10:00", "11:00", "11:00", "12:00", false},
+		{"any overlap", Rule{}, "10:00", "11:00", "10:59", "12:00", true},
+		{"exactly the minutes", Rule{MinOverlapMinutes: 15},
+			"10:00", "11:00", "10:45", "12:00", true},
+		{"short of the minutes", Rule{MinOverlapMinutes: 15},
+			"10:00", "11:00", "10:46", "12:00", false},
+		{"exactly the percent", Rule{MinOverlapPercent: 50},
+			"10:00", "11:00", "10:30", "12:00", true},
+		{"short of the percent", Rule{MinOverlapPercent: 50},
+			"10:00", "11:00", "10:31", "12:00", false},
+		{"whole invite", Rule{MinOverlapPercent: 100},
+			"10:00", "11:00", "09:00", "12:00", true},
+		{"minutes but not percent",
+			Rule{MinOverlapMinutes: 15, MinOverlapPercent: 50},
+			"10:00", "11:00", "10:40", "12:00", false},
+		{"buffer before reaches", Rule{BufferBeforeMinutes: 10},
+			"10:00", "10:56", "11:05", "12:00", true},
+		{"buffer before touches", Rule{BufferBeforeMinutes: 10},
+			"10:00", "10:55", "11:05", "12:00", false},
+		{"buffer after reaches", Rule{BufferAfterMinutes: 5},
+			"12:04", "13:00", "11:00", "12:00", true},
+		{"buffer after touches", Rule{BufferAfterMinutes: 5},
+			"12:05", "13:00", "11:00", "12:00", false},
+		{"buffer counts toward the minimum",
+			Rule{BufferAfterMinutes: 15, MinOverlapMinutes: 15},
+			"12:00", "13:00", "11:00", "12:00", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			blockerStart, blockerEnd := test.rule.pad(
+				at(test.blockerFrom), at(test.blockerTo))
+			got := test.rule.enoughOverlap(at(test.inviteFrom),
+				at(test.inviteTo), blockerStart, blockerEnd)
+			if got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRuleCompileErrors(t *testing.T) {
+	for _, rule := range []Rule{
+		{MinOverlapMinutes: -1},
+		{MinOverlapPercent: 101},
+		{BufferBeforeMinutes: -5},
+		{BufferAfterMinutes: 24*60 + 1},
+	} {
+		if err := rule.compile(); err == nil {
+			t.Errorf("%+v: expected an error", rule)
+		}
+	}
+}
+
+// memoryTimes is a TimeStore in a map.
+type memoryTimes map[string]EventTimes
+
+func (m memoryTimes) Get(ctx context.Context, calId string,
+	eventIds []string) (map[string]EventTimes, error) {
+	rv := map[string]EventTimes{}
+	for _, id := range eventIds {
+		if times, ok := m[id]; ok {
+			rv[id] = times
+		}
+	}
+	return rv, nil
+}
+
+func (m memoryTimes) Put(ctx context.Context, calId string,
+	times map[string]EventTimes) error {
+	for id, t := range times {
+		m[id] = t
+	}
+	return nil
+}
+
+func (m memoryTimes) Delete(ctx context.Context, calId string,
+	eventIds []string) error {
+	for _, id := range eventIds {
+		delete(m, id)
+	}
+	return nil
+}
+
+func TestMovedOldInvite(t *testing.T) {
+	now := time.Now()
+	base := now.UTC().Truncate(24 * time.Hour).Add(48 * time.Hour)
+	for _, test := range []struct {
+		name string
+		// seeded is whether a full sync stored the invite's times before it
+		// moved.
+		seeded bool
+	}{
+		{"seeded", true},
+		{"unknown", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := &fakeCalendar{timeZone: "America/New_York"}
+			f.addBlocker("focus", base.Add(10*time.Hour), base.Add(12*time.Hour))
+			f.addInvite("old", now.Add(-30*24*time.Hour),
+				base.Add(14*time.Hour), base.Add(15*time.Hour))
+			invite := f.events[1]
+			invite.Updated = invite.Created
+			srv := f.service(t)
+			opts := Options{
+				Rules:          DefaultRuleSet("(autoreject)"),
+				OldestCreation: now.Add(-time.Hour),
+				Times:          memoryTimes{},
+			}
+
+			if test.seeded {
+				decisions, err := RejectBadInvites(context.Background(), srv,
+					"primary", "", opts, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(decisions) != 0 {
+					t.Fatalf("got %d decisions before the move", len(decisions))
+				}
+			}
+
+			invite.Start = dateTime(base.Add(11 * time.Hour).Format(time.RFC3339))
+			invite.End = dateTime(base.Add(12 * time.Hour).Format(time.RFC3339))
+			invite.Updated = now.Format(time.RFC3339)
+			decisions, err := RejectBadInvites(context.Background(), srv,
+				"primary", "sync:next", opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(decisions) != 1 || decisions[0].InviteId != "old" ||
+				decisions[0].BlockerId != "focus" {
+				t.Fatalf("got %+v, want the moved invite declined", decisions)
+			}
+		})
+	}
+}
+
+func TestSeriesConflicts(t *testing.T) {
+	base := time.Now().UTC().Truncate(24 * time.Hour).Add(48 * time.Hour)
+	for _, test := range []struct {
+		name        string
+		conflicting int
+		percent     float64
+		want        bool
+	}{
+		{"none", 0, 10, false},
+		{"exactly the percent", 2, 50, false},
+		{"over the percent", 2, 49, true},
+		{"most", 3, 74, true},
+		{"not enough", 3, 75, false},
+		{"all", 4, 99, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := &fakeCalendar{timeZone: "UTC"}
+			for i := 0; i < 4; i++ {
+				start := base.Add(time.Duration(i)*7*24*time.Hour + 10*time.Hour)
+				if i < test.conflicting {
+					f.addBlocker(fmt.Sprintf("blocker-%d", i),
+						start.Add(30*time.Minute), start.Add(2*time.Hour))
+				}
+				f.addInvite(fmt.Sprintf("weekly_%d", i), base,
+					start, start.Add(time.Hour))
+				f.events[len(f.events)-1].RecurringEventId = "weekly"
+			}
+			r := &rejecter{ctx: context.Background(), srv: f.service(t),
+				calId: "primary",
+				opts:  Options{Rules: DefaultRuleSet("(autoreject)")}}
+			c := candidate{item: f.events[len(f.events)-1]}
+			got, err := r.seriesConflicts(c, time.UTC,
+				&Rule{SeriesPercent: test.percent})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{DefaultWorkingHours, "mon 09:00-17:00; tue 09:00-17:00; " +
+			"wed 09:00-17:00; thu 09:00-17:00; fri 09:00-17:00"},
+		{"mon 09:00-12:00,13:00-17:00\nsat 10:00-12:00",
+			"mon 09:00-12:00,13:00-17:00; sat 10:00-12:00"},
+		{"fri-mon 22:00-24:00", "sun 22:00-24:00; mon 22:00-24:00; " +
+			"fri 22:00-24:00; sat 22:00-24:00"},
+		{"Tuesday 08:30-09:00; tue 10:00-11:00",
+			"tue 08:30-09:00,10:00-11:00"},
+		{"", ""},
+	} {
+		sched, err := ParseSchedule(test.in)
+		if err != nil {
+			t.Fatalf("%q: %v", test.in, err)
+		}
+		if got := sched.String(); got != test.want {
+			t.Fatalf("%q: got %q, want %q", test.in, got, test.want)
+		}
+	}
+	for _, in := range []string{
+		"mon", "mon 09:00", "mon 17:00-09:00", "mon 09:00-09:00",
+		"mon 09:00-25:00", "mon 09:60-10:00", "someday 09:00-17:00",
+		"mon 09:00-17:00 extra",
+	} {
+		if _, err := ParseSchedule(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestWorkingSpans(t *testing.T) {
+	loc, err := calendarLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched, err := ParseSchedule("sun 00:00-24:00; sat 01:00-04:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name       string
+		day        string
+		start, end string
+	}{
+		{"ordinary sunday", "2026-03-01T12:00:00-05:00",
+			"2026-03-01T00:00:00-05:00", "2026-03-02T00:00:00-05:00"},
+		{"spring forward is 23 hours", "2026-03-08T12:00:00-04:00",
+			"2026-03-08T00:00:00-05:00", "2026-03-09T00:00:00-04:00"},
+		{"fall back is 25 hours", "2026-11-01T12:00:00-05:00",
+			"2026-11-01T00:00:00-04:00", "2026-11-02T00:00:00-05:00"},
+		{"day before spring forward", "2026-03-07T23:00:00-05:00",
+			"2026-03-07T01:00:00-05:00", "2026-03-07T04:00:00-05:00"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			spans := sched.workingSpans(mustTime(t, test.day).In(loc))
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			if !spans[0][0].Equal(mustTime(t, test.start)) ||
+				!spans[0][1].Equal(mustTime(t, test.end)) {
+				t.Fatalf("got %v-%v, want %s-%s", spans[0][0], spans[0][1],
+					test.start, test.end)
+			}
+		})
+	}
+	if spans := sched.workingSpans(
+		mustTime(t, "2026-03-02T12:00:00-05:00").In(loc)); len(spans) != 0 {
+		t.Fatalf("got %v on a day off", spans)
+	}
+}
+
+// meeting returns an invite the user has responded to with status.
+func meeting(tb testing.TB, id, from, to, status string) *calendar.Event {
+	return &calendar.Event{
+		Id:        id,
+		Summary:   "meeting " + id,
+		Organizer: &calendar.EventOrganizer{Email: "organizer@example.com"},
+		Start:     dateTime(mustTime(tb, from).Format(time.RFC3339)),
+		End:       dateTime(mustTime(tb, to).Format(time.RFC3339)),
+		Attendees: []*calendar.EventAttendee{{
+			Email: "me@example.com", Self: true, ResponseStatus: status}},
+	}
+}
+
+func testCandidate(tb testing.TB, item *calendar.Event,
+	loc *time.Location) candidate {
+	start, end, err := eventSpan(item, loc)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return candidate{item: item, start: start, end: end}
+}
+
+func TestLoad(t *testing.T) {
+	loc, err := calendarLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []*calendar.Event{
+		meeting(t, "a", "2026-03-02T09:00:00-05:00",
+			"2026-03-02T10:00:00-05:00", "accepted"),
+		meeting(t, "b", "2026-03-02T09:30:00-05:00",
+			"2026-03-02T11:00:00-05:00", "tentative"),
+		meeting(t, "c", "2026-03-02T13:00:00-05:00",
+			"2026-03-02T14:00:00-05:00", "declined"),
+		meeting(t, "d", "2026-03-02T14:00:00-05:00",
+			"2026-03-02T15:00:00-05:00", "needsAction"),
+		meeting(t, "e", "2026-03-02T23:00:00-05:00",
+			"2026-03-03T01:00:00-05:00", "accepted"),
+		{Id: "blocker", Summary: "busy (autoreject)",
+			Start: dateTime("2026-03-02T15:00:00-05:00"),
+			End:   dateTime("2026-03-02T17:00:00-05:00")},
+		{Id: "offsite", Summary: "offsite",
+			Start: date("2026-03-02"), End: date("2026-03-03"),
+			Attendees: []*calendar.EventAttendee{{
+				Email: "me@example.com", Self: true,
+				ResponseStatus: "accepted"}}},
+	}
+	r := &rejecter{calId: "primary",
+		opts: Options{Rules: DefaultRuleSet("(autoreject)")}}
+	for _, test := range []struct {
+		name     string
+		from, to string
+		want     time.Duration
+	}{
+		{"separate", "2026-03-02T11:00:00-05:00",
+			"2026-03-02T12:00:00-05:00", 4 * time.Hour},
+		{"overlapping", "2026-03-02T10:30:00-05:00",
+			"2026-03-02T11:30:00-05:00", 3*time.Hour + 30*time.Minute},
+		{"over a declined meeting", "2026-03-02T13:00:00-05:00",
+			"2026-03-02T14:00:00-05:00", 4 * time.Hour},
+		{"past the end of the day", "2026-03-02T23:30:00-05:00",
+			"2026-03-03T00:30:00-05:00", 3 * time.Hour},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := testCandidate(t, meeting(t, "new", test.from, test.to,
+				"needsAction"), loc)
+			start, end := dayOf(c.start, loc)
+			got, err := r.load(c, loc, events, start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	r.opts.DailyLoad = 3 * time.Hour
+	for _, test := range []struct {
+		name string
+		c    candidate
+		want string
+	}{
+		{"over", testCandidate(t, meeting(t, "new",
+			"2026-03-02T11:00:00-05:00", "2026-03-02T12:00:00-05:00",
+			"needsAction"), loc), "over the daily meeting load"},
+		{"all-day", testCandidate(t, &calendar.Event{Id: "conference",
+			Start: date("2026-03-02"), End: date("2026-03-03")}, loc), ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := r.overLoad(test.c, loc, events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTakesFloatingBlock(t *testing.T) {
+	loc, err := calendarLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := ParseFloatingBlocks("mon-fri 11:30-14:00 45m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &rejecter{calId: "primary", opts: Options{
+		Rules: DefaultRuleSet("(autoreject)"), FloatingBlocks: blocks}}
+	invite := meeting(t, "new", "2026-03-02T13:15:00-05:00",
+		"2026-03-02T14:00:00-05:00", "needsAction")
+	blocker := &calendar.Event{Id: "blocker", Summary: "busy (autoreject)",
+		Start: dateTime("2026-03-02T12:00:00-05:00"),
+		End:   dateTime("2026-03-02T13:15:00-05:00")}
+	for _, test := range []struct {
+		name   string
+		invite *calendar.Event
+		events []*calendar.Event
+		want   bool
+	}{
+		{"free around it", invite, nil, false},
+		{"last slot next to a blocker", invite,
+			[]*calendar.Event{blocker}, true},
+		{"last slot next to a meeting", invite, []*calendar.Event{
+			meeting(t, "lunch", "2026-03-02T12:00:00-05:00",
+				"2026-03-02T13:15:00-05:00", "accepted")}, true},
+		{"last slot next to an event without attendees", invite,
+			[]*calendar.Event{{Id: "errand", Summary: "errand",
+				Start: dateTime("2026-03-02T12:00:00-05:00"),
+				End:   dateTime("2026-03-02T13:15:00-05:00")}}, true},
+		{"next to a transparent event", invite,
+			[]*calendar.Event{{Id: "reminder", Summary: "reminder",
+				Transparency: "transparent",
+				Start:        dateTime("2026-03-02T12:00:00-05:00"),
+				End:          dateTime("2026-03-02T13:15:00-05:00")}}, false},
+		{"next to a declined meeting", invite, []*calendar.Event{
+			meeting(t, "lunch", "2026-03-02T11:30:00-05:00",
+				"2026-03-02T13:15:00-05:00", "declined")}, false},
+		{"outside the window", meeting(t, "new", "2026-03-02T15:00:00-05:00",
+			"2026-03-02T16:00:00-05:00", "needsAction"),
+			[]*calendar.Event{blocker}, false},
+		{"not on the day", meeting(t, "new", "2026-03-07T13:15:00-05:00",
+			"2026-03-07T14:00:00-05:00", "needsAction"),
+			[]*calendar.Event{blocker}, false},
+		{"all-day", &calendar.Event{Id: "offsite",
+			Start: date("2026-03-02"), End: date("2026-03-03")},
+			[]*calendar.Event{blocker}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := r.takesFloatingBlock(testCandidate(t, test.invite, loc),
+				loc, test.events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got != nil) != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHasFreeSlot(t *testing.T) {
+	at := func(clock string) time.Time {
+		return mustTime(t, "2026-03-02T"+clock+":00Z")
+	}
+	busy := mergeRanges([]timeRange{
+		{at("09:00"), at("11:45")},
+		{at("12:15"), at("13:00")},
+		{at("13:30"), at("15:00")},
+	})
+	for _, test := range []struct {
+		name       string
+		start, end string
+		length     time.Duration
+		want       bool
+	}{
+		{"before the first busy time", "08:00", "12:00", time.Hour, true},
+		{"too short everywhere", "11:00", "14:00", 31 * time.Minute, false},
+		{"exactly long enough", "11:00", "14:00", 30 * time.Minute, true},
+		{"at the end of the window", "14:00", "16:00", time.Hour, true},
+		{"window ends first", "14:00", "15:30", time.Hour, false},
+		{"all busy", "09:30", "11:30", time.Minute, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := hasFreeSlot(busy, at(test.start), at(test.end), test.length)
+			if got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestJoinRanges(t *testing.T) {
+	at := func(clock string) time.Time {
+		return mustTime(t, "2026-03-02T"+clock+":00Z")
+	}
+	ranges := []timeRange{
+		{at("13:00"), at("14:00")},
+		{at("09:00"), at("10:00")},
+		{at("10:04"), at("11:00")},
+		{at("10:30"), at("11:30")},
+		{at("11:35"), at("12:00")},
+	}
+	for _, test := range []struct {
+		name string
+		gap  time.Duration
+		want [][2]string
+	}{
+		{"no gap", 0, [][2]string{{"09:00", "10:00"}, {"10:04", "11:30"},
+			{"11:35", "12:00"}, {"13:00", "14:00"}}},
+		{"short gaps", 5 * time.Minute, [][2]string{{"09:00", "11:30"},
+			{"11:35", "12:00"}, {"13:00", "14:00"}}},
+		{"exactly the gap apart", 5*time.Minute + time.Second,
+			[][2]string{{"09:00", "12:00"}, {"13:00", "14:00"}}},
+		{"everything", time.Hour + time.Second,
+			[][2]string{{"09:00", "14:00"}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := joinRanges(append([]timeRange(nil), ranges...), test.gap)
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			for i, want := range test.want {
+				if !got[i].start.Equal(at(want[0])) ||
+					!got[i].end.Equal(at(want[1])) {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackToBack(t *testing.T) {
+	loc, err := calendarLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []*calendar.Event{
+		meeting(t, "a", "2026-03-02T09:00:00-05:00",
+			"2026-03-02T10:00:00-05:00", "accepted"),
+		meeting(t, "b", "2026-03-02T10:05:00-05:00",
+			"2026-03-02T11:00:00-05:00", "accepted"),
+		meeting(t, "c", "2026-03-02T13:00:00-05:00",
+			"2026-03-02T14:00:00-05:00", "declined"),
+	}
+	r := &rejecter{calId: "primary", opts: Options{
+		Rules:           DefaultRuleSet("(autoreject)"),
+		BackToBackLimit: 2*time.Hour + 30*time.Minute,
+		BackToBackGap:   10 * time.Minute,
+	}}
+	for _, test := range []struct {
+		name   string
+		invite *calendar.Event
+		want   bool
+	}{
+		{"ends the run in time", meeting(t, "new",
+			"2026-03-02T11:00:00-05:00", "2026-03-02T11:30:00-05:00",
+			"needsAction"), false},
+		{"makes the run too long", meeting(t, "new",
+			"2026-03-02T11:05:00-05:00", "2026-03-02T11:45:00-05:00",
+			"needsAction"), true},
+		{"after a long enough gap", meeting(t, "new",
+			"2026-03-02T11:10:00-05:00", "2026-03-02T12:30:00-05:00",
+			"needsAction"), false},
+		{"next to a declined meeting", meeting(t, "new",
+			"2026-03-02T11:30:00-05:00", "2026-03-02T13:00:00-05:00",
+			"needsAction"), false},
+		{"all-day", &calendar.Event{Id: "offsite",
+			Start: date("2026-03-02"), End: date("2026-03-03")}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := r.backToBack(testCandidate(t, test.invite, loc), loc,
+				events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOptionalNeverStronger(t *testing.T) {
+	base := time.Now().UTC().Truncate(24 * time.Hour).Add(48 * time.Hour)
+	for _, test := range []struct {
+		name     string
+		rules    string
+		want     Action
+		optional bool
+	}{
+		{"decline", `{"rules": [{"summary_contains": "(autoreject)"}]}`,
+			ActionDecline, true},
+		{"tentative", `{"rules": [{"summary_contains": "(autoreject)",
+			"action": "tentative"}]}`, ActionTentative, true},
+		{"color", `{"rules": [{"summary_contains": "(autoreject)",
+			"action": "color", "color": "8"}]}`, ActionColor, true},
+		{"rule's own optional response", `{"rules": [{
+			"summary_contains": "(autoreject)", "action": "tentative",
+			"optional": {"action": "decline", "notify": "none"}}]}`,
+			ActionDecline, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := &fakeCalendar{timeZone: "UTC"}
+			f.addBlocker("focus", base.Add(10*time.Hour), base.Add(12*time.Hour))
+			f.addInvite("invite", base.Add(-time.Hour),
+				base.Add(11*time.Hour), base.Add(12*time.Hour))
+			f.events[1].Attendees[1].Optional = true
+			rules, err := ParseRuleSet([]byte(test.rules))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decisions, err := RejectBadInvites(context.Background(),
+				f.service(t), "primary", "", Options{
+					Rules: rules,
+					Optional: Policy{Enabled: true,
+						Response: Response{Notify: "none"}},
+				}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(decisions) != 1 {
+				t.Fatalf("got %d decisions, want 1", len(decisions))
+			}
+			d := decisions[0]
+			if d.Action != test.want || d.Optional != test.optional {
+				t.Fatalf("got %s (optional %v), want %s (optional %v)",
+					d.Action, d.Optional, test.want, test.optional)
+			}
+			// the optional notify setting applies either way
+			if f.patches != 1 || f.updates[0] != "none" {
+				t.Fatalf("got patches %+v sent to %q, want one sent to none",
+					f.patched, f.updates)
+			}
+			if test.want == ActionColor && f.patched[0].ColorId != "8" {
+				t.Fatalf("got color %q, want 8", f.patched[0].ColorId)
+			}
+		})
+	}
+}
+
+func TestRuleSetValidate(t *testing.T) {
+	rs := DefaultRuleSet("(autoreject)")
+	rs.EventTypes = append(rs.EventTypes, "outOfOffice", "focusTime")
+	rs.WorkingLocations = append(rs.WorkingLocations, "notInOffice")
+	if err := rs.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []*RuleSet{
+		{EventTypes: []string{"outofoffice"}},
+		{EventTypes: []string{"workingLocation"}},
+		{WorkingLocations: []string{"home"}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", bad)
+		}
+	}
+}
+
+func TestNewBlockerOverPendingInvites(t *testing.T) {
+	now := time.Now()
+	base := now.UTC().Truncate(24 * time.Hour).Add(48 * time.Hour)
+	f := &fakeCalendar{timeZone: "UTC"}
+	f.addInvite("recent", now.Add(-time.Hour),
+		base.Add(11*time.Hour), base.Add(12*time.Hour))
+	f.addInvite("old", now.Add(-30*24*time.Hour),
+		base.Add(13*time.Hour), base.Add(14*time.Hour))
+	f.addInvite("elsewhere", now.Add(-time.Hour),
+		base.Add(16*time.Hour), base.Add(17*time.Hour))
+	f.addBlocker("focus", base.Add(10*time.Hour), base.Add(15*time.Hour))
+	// the incremental page only has the new blocker
+	f.changes = f.events[3:]
+
+	decisions, err := RejectBadInvites(context.Background(), f.service(t),
+		"primary", "sync:previous", Options{
+			Rules:          DefaultRuleSet("(autoreject)"),
+			OldestCreation: now.Add(-2 * time.Hour),
+		}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].InviteId != "recent" ||
+		decisions[0].BlockerId != "focus" ||
+		decisions[0].Action != ActionDecline {
+		t.Fatalf("got %+v, want only the recent invite declined", decisions)
+	}
+	if f.patches != 1 || f.patched[0].Id != "recent" ||
+		f.patched[0].Attendees[0].ResponseStatus != "declined" {
+		t.Fatalf("got patches %+v, want the recent invite declined", f.patched)
+	}
+}
+
+// memoryLog is a DecisionLog in a slice.
+type memoryLog struct {
+	decisions []*Decision
+	resolved  map[string]bool
+}
+
+func (m *memoryLog) Record(ctx context.Context, d *Decision) error {
+	d.Id = strconv.Itoa(len(m.decisions))
+	m.decisions = append(m.decisions, d)
+	if !d.Standing() {
+		m.resolved[d.Id] = true
+	}
+	return nil
+}
+
+func (m *memoryLog) ByBlocker(ctx context.Context, calId, blockerId string) (
+	rv []*Decision, err error) {
+	for _, d := range m.decisions {
+		if d.BlockerId == blockerId && !m.resolved[d.Id] {
+			rv = append(rv, d)
+		}
+	}
+	return rv, nil
+}
+
+func (m *memoryLog) Resolve(ctx context.Context, d *Decision) error {
+	m.resolved[d.Id] = true
+	return nil
+}
+
+func TestRestoreOnBlockerChange(t *testing.T) {
+	now := time.Now()
+	base := now.UTC().Truncate(24 * time.Hour).Add(48 * time.Hour)
+	for _, test := range []struct {
+		name string
+		// other is whether another blocker still covers the invite.
+		other bool
+	}{
+		{"withdrawn", false},
+		{"handed off", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := &fakeCalendar{timeZone: "UTC"}
+			f.addInvite("meeting", now.Add(-time.Hour),
+				base.Add(11*time.Hour), base.Add(12*time.Hour))
+			f.events[0].Attendees[1].ResponseStatus = "declined"
+			f.events[0].Attendees[1].Comment = "busy"
+			if test.other {
+				f.addBlocker("other", base.Add(10*time.Hour),
+					base.Add(13*time.Hour))
+			}
+			// the incremental page only has the removed blocker
+			f.changes = []*calendar.Event{{Id: "focus", Status: "cancelled"}}
+
+			log := &memoryLog{resolved: map[string]bool{}}
+			old := &Decision{
+				InviteId:  "meeting",
+				BlockerId: "focus",
+				Blocker:   "focus (autoreject)",
+				Action:    ActionDecline,
+			}
+			err := log.Record(context.Background(), old)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = RejectBadInvites(context.Background(), f.service(t),
+				"primary", "sync:previous", Options{
+					Rules:         DefaultRuleSet("(autoreject)"),
+					Log:           log,
+					RestoreStatus: "accepted",
+				}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !log.resolved[old.Id] {
+				t.Fatalf("old decision not resolved")
+			}
+			if test.other {
+				if f.patches != 0 {
+					t.Fatalf("got patches %+v, want none", f.patched)
+				}
+				if len(log.decisions) != 2 ||
+					log.decisions[1].BlockerId != "other" ||
+					log.resolved[log.decisions[1].Id] {
+					t.Fatalf("got %+v, want the decline handed to other",
+						log.decisions)
+				}
+				return
+			}
+			if f.patches != 1 || f.patched[0].Id != "meeting" ||
+				f.patched[0].Attendees[0].ResponseStatus != "accepted" {
+				t.Fatalf("got patches %+v, want the meeting accepted", f.patched)
+			}
+			if !strings.Contains(f.bodies[0], `"comment":""`) {
+				t.Fatalf("got patch %s, want the comment cleared", f.bodies[0])
+			}
+			if len(log.decisions) != 2 ||
+				log.decisions[1].Action != ActionRestore {
+				t.Fatalf("got %+v, want the withdrawal recorded", log.decisions)
+			}
+		})
+	}
+}
+
+func TestUndoable(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		decision Decision
+		want     bool
+	}{
+		{"standing", Decision{BlockerId: "focus", Action: ActionDecline}, true},
+		{"withdrawn", Decision{BlockerId: "focus", Action: ActionDecline,
+			Resolved: true}, false},
+		{"policy", Decision{Policy: "outside working hours",
+			Action: ActionTentative, Resolved: true}, true},
+		{"undone", Decision{Policy: "outside working hours",
+			Action: ActionDecline, Resolved: true, Undone: true}, false},
+		{"restore", Decision{BlockerId: "focus", Action: ActionRestore}, false},
+	} {
+		if got := test.decision.Undoable(); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMovedOwnMeeting(t *testing.T) {
+	now := time.Now()
+	base := now.UTC().Truncate(24 * time.Hour).Add(48 * time.Hour)
+	f := &fakeCalendar{timeZone: "UTC"}
+	f.addBlocker("focus", base.Add(10*time.Hour), base.Add(12*time.Hour))
+	f.addInvite("own", now.Add(-time.Hour),
+		base.Add(14*time.Hour), base.Add(15*time.Hour))
+	meeting := f.events[1]
+	meeting.Organizer = &calendar.EventOrganizer{
+		Email: "me@example.com", Self: true}
+	meeting.Attendees[0].Organizer = false
+	meeting.Attendees[1].Organizer = true
+	meeting.Attendees[1].ResponseStatus = "accepted"
+	meeting.Updated = meeting.Created
+	srv := f.service(t)
+	opts := Options{
+		Rules:          DefaultRuleSet("(autoreject)"),
+		OldestCreation: now.Add(-2 * time.Hour),
+		Times:          memoryTimes{},
+		MovedAccepted:  MovedRespond,
+	}
+
+	_, err := RejectBadInvites(context.Background(), srv, "primary", "", opts,
+		nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meeting.Start = dateTime(base.Add(11 * time.Hour).Format(time.RFC3339))
+	meeting.End = dateTime(base.Add(12 * time.Hour).Format(time.RFC3339))
+	meeting.Updated = now.Format(time.RFC3339)
+	decisions, err := RejectBadInvites(context.Background(), srv,
+		"primary", "sync:next", opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 0 || f.patches != 0 {
+		t.Fatalf("got %+v, want the user's own meeting left alone", decisions)
+	}
+}
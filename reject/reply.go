@@ -0,0 +1,105 @@
+package reject
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// slotsSuffix ends default replies that offer the suggested free slots.
+const slotsSuffix = "{{if .Slots}} Some times that are free: " +
+	"{{whenAll .Slots}}.{{end}}"
+
+// DefaultReply is the reply used unless one is configured.
+const DefaultReply = "Automatic decline - unavailable. Please consider " +
+	"scheduling this during free time at a later date." + slotsSuffix
+
+// ReplyData is what reply templates can refer to. Times are in the
+// organizer's time zone when it is known.
+type ReplyData struct {
+	OrganizerName  string
+	OrganizerEmail string
+	Title          string
+	Start          time.Time
+	End            time.Time
+	Blocker        string
+	BlockerEnd     time.Time
+	// Reason explains which policy the invite failed, if one decided it.
+	Reason string
+	// NextFree is the first suggested free slot, or nil if there are none.
+	NextFree *time.Time
+	// Slots are all the suggested free slots.
+	Slots []time.Time
+}
+
+var replyFuncs = template.FuncMap{
+	"when": func(t time.Time) string {
+		return t.Format("Mon Jan 2 15:04 MST")
+	},
+	"whenAll": func(ts []time.Time) string {
+		formatted := make([]string, 0, len(ts))
+		for _, t := range ts {
+			formatted = append(formatted, t.Format("Mon Jan 2 15:04 MST"))
+		}
+		return strings.Join(formatted, ", ")
+	},
+}
+
+// Reply is a text/template for the comment left on invites.
+type Reply struct {
+	t *template.Template
+}
+
+// ParseReply parses a reply template and tries it out on sample data, so
+// templates that would fail during a sync are rejected up front.
+func ParseReply(text string) (*Reply, error) {
+	t, err := template.New("reply").Funcs(replyFuncs).Option(
+		"missingkey=error").Parse(text)
+	if err != nil {
+		return nil, Err.Wrap(err)
+	}
+	r := &Reply{t: t}
+	start := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+	for _, data := range []*ReplyData{
+		{}, {
+			OrganizerName:  "Organizer",
+			OrganizerEmail: "organizer@example.com",
+			Title:          "Meeting",
+			Start:          start,
+			End:            start.Add(time.Hour),
+			Blocker:        "Blocker",
+			BlockerEnd:     start.Add(2 * time.Hour),
+			Reason:         "a reason",
+			NextFree:       &start,
+			Slots:          []time.Time{start, start.Add(24 * time.Hour)},
+		}} {
+		_, err = r.Render(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// MustParseReply is ParseReply for templates known to be good.
+func MustParseReply(text string) *Reply {
+	r, err := ParseReply(text)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Render fills in the template.
+func (r *Reply) Render(data *ReplyData) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	err := r.t.Execute(&buf, data)
+	if err != nil {
+		return "", Err.Wrap(err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,205 @@
+package reject
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// DecisionLog stores every decision that was acted on.
+type DecisionLog interface {
+	// Record stores a decision that was acted on and sets its Id. Decisions
+	// that aren't Standing start out resolved.
+	Record(ctx context.Context, d *Decision) error
+	// ByBlocker returns the unresolved decisions blockerId caused.
+	ByBlocker(ctx context.Context, calId, blockerId string) ([]*Decision, error)
+	// Resolve marks a decision as no longer standing, because it was
+	// withdrawn, superseded or overridden by hand.
+	Resolve(ctx context.Context, d *Decision) error
+}
+
+type restoreCandidate struct {
+	candidate
+	decision *Decision
+}
+
+// restoreCandidates finds the invites responded to because of blockers that
+// changed on this page, and that still carry our response. Only incremental
+// syncs can change blockers decisions were made for.
+func (r *rejecter) restoreCandidates(e *calendar.Events, loc *time.Location) (
+	restores []restoreCandidate, err error) {
+	if r.opts.Log == nil || !r.incremental {
+		return nil, nil
+	}
+	for _, item := range e.Items {
+		if item.Status != "cancelled" &&
+			r.opts.Rules.Matches(r.calId, item) == nil {
+			// not a blocker, so no decision can refer to it
+			continue
+		}
+		decisions, err := r.opts.Log.ByBlocker(r.ctx, r.calId, item.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range decisions {
+			invite, err := r.srv.Events.Get(r.calId, d.InviteId).
+				Context(r.ctx).Do()
+			if err != nil {
+				if gerr, ok := err.(*googleapi.Error); ok &&
+					(gerr.Code == http.StatusNotFound || gerr.Code == http.StatusGone) {
+					err = r.resolve(d)
+					if err != nil {
+						return nil, err
+					}
+					continue
+				}
+				return nil, Err.Wrap(err)
+			}
+			if !stillResponded(invite, d) {
+				err = r.resolve(d)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			start, end, err := eventSpan(invite, loc)
+			if err != nil {
+				return nil, err
+			}
+			if d.Series {
+				start, end = d.Start, d.End
+			}
+			if end.Before(time.Now()) {
+				continue
+			}
+			restores = append(restores, restoreCandidate{
+				candidate: candidate{item: invite, start: start, end: end},
+				decision:  d,
+			})
+		}
+	}
+	return restores, nil
+}
+
+// stillResponded returns whether invite still has the response d gave it.
+func stillResponded(invite *calendar.Event, d *Decision) bool {
+	self := selfAttendee(invite)
+	if invite.Status == "cancelled" || self == nil {
+		return false
+	}
+	switch self.ResponseStatus {
+	case "declined":
+		return d.Action == ActionDecline
+	case "tentative":
+		return d.Action == ActionTentative
+	}
+	return false
+}
+
+func (r *rejecter) resolve(d *Decision) error {
+	if r.opts.DryRun {
+		return nil
+	}
+	return r.opts.Log.Resolve(r.ctx, d)
+}
+
+// reconsider withdraws a response if the invite no longer conflicts with any
+// blocker, or hands it to the blocker it conflicts with now.
+func (r *rejecter) reconsider(restore restoreCandidate, loc *time.Location,
+	blockers *intervalIndex) error {
+	c, old := restore.candidate, restore.decision
+	if conflict := r.conflict(c, blockers); conflict != nil {
+		if conflict.event.Id == old.BlockerId {
+			return nil
+		}
+		if r.opts.DryRun {
+			return nil
+		}
+		d := *old
+		d.Id = ""
+		d.Time = time.Now()
+		d.BlockerId = conflict.event.Id
+		d.Blocker = conflict.event.Summary
+		d.Rule = conflict.rule.Name
+		err := r.opts.Log.Record(r.ctx, &d)
+		if err != nil {
+			return err
+		}
+		return r.opts.Log.Resolve(r.ctx, old)
+	}
+
+	d := r.decision(c, ActionRestore)
+	d.BlockerId = old.BlockerId
+	d.Blocker = old.Blocker
+	d.Rule = old.Rule
+	r.decisions = append(r.decisions, d)
+
+	orgLoc := organizerLocation(c.item, loc)
+	data := &ReplyData{
+		OrganizerEmail: organizerEmail(c.item),
+		Title:          c.item.Summary,
+		Start:          c.start.In(orgLoc),
+		End:            c.end.In(orgLoc),
+		Blocker:        old.Blocker,
+	}
+	if c.item.Organizer != nil {
+		data.OrganizerName = c.item.Organizer.DisplayName
+	}
+	comment := ""
+	if r.opts.RestoreNotify {
+		var err error
+		comment, err = r.opts.RestoreReply.Render(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.opts.DryRun {
+		return nil
+	}
+	err := Withdraw(r.ctx, r.srv, r.calId, c.item, r.opts.RestoreStatus,
+		comment, r.opts.RestoreNotify)
+	if err != nil {
+		return err
+	}
+	err = r.opts.Log.Resolve(r.ctx, old)
+	if err != nil {
+		return err
+	}
+	return r.record(d)
+}
+
+// Withdraw sets the user's response to invite back to status
+// ("needsAction" if empty) with the given comment, clearing ours.
+func Withdraw(ctx context.Context, srv *calendar.Service, calId string,
+	invite *calendar.Event, status, comment string, notify bool) error {
+	if status == "" {
+		status = "needsAction"
+	}
+	self := selfAttendee(invite)
+	if self == nil {
+		return Err.New("invite %q has no attendee entry to restore", invite.Id)
+	}
+	sendUpdates := "none"
+	if notify {
+		sendUpdates = "all"
+	}
+	_, err := srv.Events.Patch(calId, invite.Id, &calendar.Event{
+		Id:    invite.Id,
+		Start: invite.Start,
+		End:   invite.End,
+		Attendees: []*calendar.EventAttendee{{
+			Email:          self.Email,
+			Id:             self.Id,
+			Optional:       self.Optional,
+			ResponseStatus: status,
+			Comment:        comment,
+			// an empty comment clears ours
+			ForceSendFields: []string{"Comment"},
+		}},
+	}).Context(ctx).SendUpdates(sendUpdates).Do()
+	return Err.Wrap(err)
+}
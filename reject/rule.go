@@ -0,0 +1,368 @@
+package reject
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Rule describes which calendar events count as blockers. Every non-empty
+// field must match for the rule to match. Text comparisons are case
+// insensitive.
+type Rule struct {
+	Name                string `json:"name,omitempty"`
+	SummaryContains     string `json:"summary_contains,omitempty"`
+	SummaryRegexp       string `json:"summary_regexp,omitempty"`
+	DescriptionContains string `json:"description_contains,omitempty"`
+	DescriptionRegexp   string `json:"description_regexp,omitempty"`
+	ColorId             string `json:"color_id,omitempty"`
+	// Transparency is "opaque" (busy) or "transparent" (free).
+	Transparency string `json:"transparency,omitempty"`
+	// EventType is "default", "outOfOffice", "focusTime" or
+	// "workingLocation".
+	EventType string `json:"event_type,omitempty"`
+	// WorkingLocation matches working location events by where they say the
+	// user is: "homeOffice", "officeLocation", "customLocation" or
+	// "notInOffice" for anything but an office.
+	WorkingLocation string `json:"working_location,omitempty"`
+	// Calendar restricts the rule to blockers on the given calendar id.
+	Calendar string `json:"calendar,omitempty"`
+	// AllowAttendees lets events with attendees count as blockers. By
+	// default only events without attendees do.
+	AllowAttendees bool `json:"allow_attendees,omitempty"`
+	// ExternalOnly makes the rule's blockers only conflict with invites
+	// from organizers outside Options.InternalDomains.
+	ExternalOnly bool `json:"external_only,omitempty"`
+	// MinOverlapMinutes and MinOverlapPercent (of the invite's length) are
+	// how much an invite has to overlap a blocker to conflict with it. If
+	// both are set, both have to be met.
+	MinOverlapMinutes int     `json:"min_overlap_minutes,omitempty"`
+	MinOverlapPercent float64 `json:"min_overlap_percent,omitempty"`
+	// BufferBeforeMinutes and BufferAfterMinutes extend each blocker.
+	BufferBeforeMinutes int `json:"buffer_before_minutes,omitempty"`
+	BufferAfterMinutes  int `json:"buffer_after_minutes,omitempty"`
+	// SeriesPercent, if set, responds to a whole recurring series at once
+	// when more than this percentage of its upcoming instances conflict.
+	// Otherwise only the conflicting instances are responded to.
+	SeriesPercent float64 `json:"series_percent,omitempty"`
+	// Response is what happens to invites that conflict with the rule's
+	// blockers.
+	Response
+	// Optional, if set, is what happens instead to invites the user is an
+	// optional attendee of, when Options.Optional is enabled.
+	Optional *Response `json:"optional,omitempty"`
+
+	summaryRe     *regexp.Regexp
+	descriptionRe *regexp.Regexp
+}
+
+func (r *Rule) compile() (err error) {
+	err = r.Response.validate()
+	if err != nil {
+		return err
+	}
+	if r.Optional != nil {
+		err = r.Optional.validate()
+		if err != nil {
+			return err
+		}
+	}
+	if r.MinOverlapMinutes < 0 || r.MinOverlapPercent < 0 ||
+		r.MinOverlapPercent > 100 {
+		return Err.New("invalid minimum overlap")
+	}
+	if r.WorkingLocation != "" && !validWorkingLocation(r.WorkingLocation) {
+		return Err.New("unknown working location %q", r.WorkingLocation)
+	}
+	if r.SeriesPercent < 0 || r.SeriesPercent > 100 {
+		return Err.New("invalid series percentage")
+	}
+	if r.BufferBeforeMinutes < 0 || r.BufferAfterMinutes < 0 ||
+		r.buffer() > maxBuffer {
+		return Err.New("buffers must be between 0 and %d minutes",
+			int(maxBuffer/time.Minute))
+	}
+	if r.SummaryRegexp != "" {
+		r.summaryRe, err = regexp.Compile("(?i)" + r.SummaryRegexp)
+		if err != nil {
+			return Err.Wrap(err)
+		}
+	}
+	if r.DescriptionRegexp != "" {
+		r.descriptionRe, err = regexp.Compile("(?i)" + r.DescriptionRegexp)
+		if err != nil {
+			return Err.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// maxBuffer keeps blocker buffers from growing the blocker fetch window too
+// much.
+const maxBuffer = 24 * time.Hour
+
+func (r *Rule) buffer() time.Duration {
+	before := time.Duration(r.BufferBeforeMinutes) * time.Minute
+	after := time.Duration(r.BufferAfterMinutes) * time.Minute
+	if before > after {
+		return before
+	}
+	return after
+}
+
+// pad extends a blocker's span by the rule's buffers.
+func (r *Rule) pad(start, end time.Time) (time.Time, time.Time) {
+	return start.Add(-time.Duration(r.BufferBeforeMinutes) * time.Minute),
+		end.Add(time.Duration(r.BufferAfterMinutes) * time.Minute)
+}
+
+// enoughOverlap returns whether the invite overlaps the (padded) blocker
+// by at least the rule's minimum.
+func (r *Rule) enoughOverlap(inviteStart, inviteEnd,
+	blockerStart, blockerEnd time.Time) bool {
+	start, end := inviteStart, inviteEnd
+	if blockerStart.After(start) {
+		start = blockerStart
+	}
+	if blockerEnd.Before(end) {
+		end = blockerEnd
+	}
+	overlap := end.Sub(start)
+	if overlap <= 0 {
+		return false
+	}
+	if overlap < time.Duration(r.MinOverlapMinutes)*time.Minute {
+		return false
+	}
+	if length := inviteEnd.Sub(inviteStart); length > 0 &&
+		float64(overlap)*100 < r.MinOverlapPercent*float64(length) {
+		return false
+	}
+	return true
+}
+
+func validWorkingLocation(s string) bool {
+	switch s {
+	case "homeOffice", "officeLocation", "customLocation", "notInOffice":
+		return true
+	}
+	return false
+}
+
+// workingLocation returns where a working location event says the user is,
+// or "" if e isn't one.
+func workingLocation(e *calendar.Event) string {
+	props := e.WorkingLocationProperties
+	switch {
+	case props == nil:
+		return ""
+	case props.OfficeLocation != nil:
+		return "officeLocation"
+	case props.HomeOffice != nil:
+		return "homeOffice"
+	case props.CustomLocation != nil:
+		return "customLocation"
+	}
+	return ""
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s),
+		strings.ToLower(strings.TrimSpace(substr)))
+}
+
+// Matches returns whether e, found on calendar calId, is a blocker according
+// to this rule.
+func (r *Rule) Matches(calId string, e *calendar.Event) bool {
+	if !r.AllowAttendees && len(e.Attendees) != 0 {
+		return false
+	}
+	if r.Calendar != "" && r.Calendar != calId {
+		return false
+	}
+	if r.SummaryContains != "" && !containsFold(e.Summary, r.SummaryContains) {
+		return false
+	}
+	if r.summaryRe != nil && !r.summaryRe.MatchString(e.Summary) {
+		return false
+	}
+	if r.DescriptionContains != "" &&
+		!containsFold(e.Description, r.DescriptionContains) {
+		return false
+	}
+	if r.descriptionRe != nil && !r.descriptionRe.MatchString(e.Description) {
+		return false
+	}
+	if r.ColorId != "" && r.ColorId != e.ColorId {
+		return false
+	}
+	if r.Transparency != "" {
+		transparency := e.Transparency
+		if transparency == "" {
+			transparency = "opaque"
+		}
+		if r.Transparency != transparency {
+			return false
+		}
+	}
+	if r.EventType != "" {
+		eventType := e.EventType
+		if eventType == "" {
+			eventType = "default"
+		}
+		if r.EventType != eventType {
+			return false
+		}
+	}
+	if r.WorkingLocation != "" {
+		where := workingLocation(e)
+		if where == "" {
+			return false
+		}
+		if r.WorkingLocation == "notInOffice" {
+			if where == "officeLocation" {
+				return false
+			}
+		} else if r.WorkingLocation != where {
+			return false
+		}
+	}
+	return true
+}
+
+// RuleSet combines rules. With Match "any" (the default) an event is a
+// blocker if any rule matches, with "all" only if every rule matches.
+// Google's own event types in EventTypes ("outOfOffice" or "focusTime") and
+// working location events in the states in WorkingLocations (see
+// Rule.WorkingLocation) are blockers as well, whatever the rules say.
+type RuleSet struct {
+	Match            string   `json:"match,omitempty"`
+	Rules            []*Rule  `json:"rules"`
+	EventTypes       []string `json:"event_types,omitempty"`
+	WorkingLocations []string `json:"working_locations,omitempty"`
+}
+
+// allEventTypes asks event lists for working location events too, which
+// they leave out by default.
+var allEventTypes = []string{
+	"default", "outOfOffice", "focusTime", "workingLocation"}
+
+// wantsWorkingLocations returns whether working location events need to be
+// listed.
+func (rs *RuleSet) wantsWorkingLocations() bool {
+	if len(rs.WorkingLocations) > 0 {
+		return true
+	}
+	for _, r := range rs.Rules {
+		if r.WorkingLocation != "" || r.EventType == "workingLocation" {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRuleSet returns the classic behavior: events without attendees
+// whose summary contains identifier are blockers.
+func DefaultRuleSet(identifier string) *RuleSet {
+	return &RuleSet{Rules: []*Rule{{SummaryContains: identifier}}}
+}
+
+// ParseRuleSet parses and validates a JSON encoded RuleSet.
+func ParseRuleSet(data []byte) (*RuleSet, error) {
+	var rs RuleSet
+	err := json.Unmarshal(data, &rs)
+	if err != nil {
+		return nil, Err.Wrap(err)
+	}
+	return &rs, rs.compile()
+}
+
+// Validate checks a RuleSet that was put together or changed in code, like
+// ParseRuleSet does for JSON ones.
+func (rs *RuleSet) Validate() error {
+	return rs.compile()
+}
+
+func (rs *RuleSet) compile() error {
+	switch rs.Match {
+	case "", "any", "all":
+	default:
+		return Err.New("unknown rule set match %q", rs.Match)
+	}
+	for _, r := range rs.Rules {
+		err := r.compile()
+		if err != nil {
+			return err
+		}
+	}
+	for _, eventType := range rs.EventTypes {
+		if eventType != "outOfOffice" && eventType != "focusTime" {
+			return Err.New("unknown blocker event type %q", eventType)
+		}
+	}
+	for _, where := range rs.WorkingLocations {
+		if !validWorkingLocation(where) {
+			return Err.New("unknown working location %q", where)
+		}
+	}
+	return nil
+}
+
+// buffer returns the largest buffer of any rule.
+func (rs *RuleSet) buffer() (max time.Duration) {
+	for _, r := range rs.Rules {
+		if b := r.buffer(); b > max {
+			max = b
+		}
+	}
+	return max
+}
+
+// Matches returns the rule that makes e a blocker, or nil if e is not one.
+func (rs *RuleSet) Matches(calId string, e *calendar.Event) *Rule {
+	if r := rs.matchesRules(calId, e); r != nil {
+		return r
+	}
+	return rs.matchesNative(calId, e)
+}
+
+func (rs *RuleSet) matchesRules(calId string, e *calendar.Event) *Rule {
+	if len(rs.Rules) == 0 {
+		return nil
+	}
+	if rs.Match == "all" {
+		for _, r := range rs.Rules {
+			if !r.Matches(calId, e) {
+				return nil
+			}
+		}
+		return rs.Rules[0]
+	}
+	for _, r := range rs.Rules {
+		if r.Matches(calId, e) {
+			return r
+		}
+	}
+	return nil
+}
+
+// matchesNative returns a rule for e if it is one of the EventTypes or
+// WorkingLocations.
+func (rs *RuleSet) matchesNative(calId string, e *calendar.Event) *Rule {
+	for _, eventType := range rs.EventTypes {
+		r := &Rule{Name: eventType, EventType: eventType}
+		if r.Matches(calId, e) {
+			return r
+		}
+	}
+	for _, where := range rs.WorkingLocations {
+		r := &Rule{Name: "working location " + where, WorkingLocation: where}
+		if r.Matches(calId, e) {
+			return r
+		}
+	}
+	return nil
+}
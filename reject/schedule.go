@@ -0,0 +1,199 @@
+package reject
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Span is a range of the day, as offsets from midnight.
+type Span struct {
+	Start, End time.Duration
+}
+
+// Schedule is a weekly schedule of working hours, indexed by weekday and
+// evaluated in the calendar's time zone.
+type Schedule [7][]Span
+
+// DefaultWorkingHours is 9 to 5, Monday through Friday.
+const DefaultWorkingHours = "mon-fri 09:00-17:00"
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range weekdayNames {
+		if strings.HasPrefix(s, name) {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, Err.New("unknown weekday %q", s)
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	var hours, minutes int
+	_, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &hours, &minutes)
+	if err != nil {
+		return 0, Err.New("invalid time of day %q", s)
+	}
+	if hours < 0 || hours > 24 || minutes < 0 || minutes > 59 ||
+		(hours == 24 && minutes != 0) {
+		return 0, Err.New("invalid time of day %q", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute,
+		nil
+}
+
+// parseDays parses days like "mon-fri,sun".
+func parseDays(s string) (days []time.Weekday, err error) {
+	for _, dayRange := range strings.Split(s, ",") {
+		ends := strings.SplitN(dayRange, "-", 2)
+		first, err := parseWeekday(ends[0])
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(ends) == 2 {
+			last, err = parseWeekday(ends[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseSpan parses a time range like "09:00-17:00".
+func parseSpan(s string) (span Span, err error) {
+	ends := strings.SplitN(s, "-", 2)
+	if len(ends) != 2 {
+		return span, Err.New("invalid time range %q", s)
+	}
+	span.Start, err = parseTimeOfDay(ends[0])
+	if err != nil {
+		return span, err
+	}
+	span.End, err = parseTimeOfDay(ends[1])
+	if err != nil {
+		return span, err
+	}
+	if span.End <= span.Start {
+		return span, Err.New("time range %q ends before it starts", s)
+	}
+	return span, nil
+}
+
+// ParseSchedule parses lines or semicolon separated entries like
+// "mon-fri 09:00-12:00,13:00-17:00" or "sat 10:00-12:00".
+func ParseSchedule(s string) (sched Schedule, err error) {
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ';' || r == '\n'
+	}) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Fields(entry)
+		if len(fields) != 2 {
+			return sched, Err.New("invalid schedule entry %q", entry)
+		}
+
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return sched, err
+		}
+
+		var spans []Span
+		for _, spanStr := range strings.Split(fields[1], ",") {
+			span, err := parseSpan(spanStr)
+			if err != nil {
+				return sched, err
+			}
+			spans = append(spans, span)
+		}
+
+		for _, day := range days {
+			sched[day] = append(sched[day], spans...)
+		}
+	}
+	return sched, nil
+}
+
+// IsZero returns whether the schedule has no working hours at all.
+func (s Schedule) IsZero() bool {
+	for _, spans := range s {
+		if len(spans) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Schedule) String() string {
+	var entries []string
+	for day, spans := range s {
+		if len(spans) == 0 {
+			continue
+		}
+		entries = append(entries,
+			weekdayNames[day]+" "+s.Ranges(time.Weekday(day)))
+	}
+	return strings.Join(entries, "; ")
+}
+
+// Ranges returns the working hours on day, like "09:00-12:00,13:00-17:00".
+func (s Schedule) Ranges(day time.Weekday) string {
+	var ranges []string
+	for _, span := range s[day] {
+		ranges = append(ranges, formatTimeOfDay(span.Start)+"-"+
+			formatTimeOfDay(span.End))
+	}
+	return strings.Join(ranges, ",")
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// formatDuration formats whole minutes like "2h30m", "3h" or "45m".
+func formatDuration(d time.Duration) string {
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%dm", hours, minutes)
+}
+
+// formatDays formats whole days like "90 days".
+func formatDays(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+func atTimeOfDay(day time.Time, offset time.Duration) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, int(offset/time.Hour),
+		int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// workingSpans returns the working hours on the calendar day of t, in t's
+// location. Wall clock times are used so days with DST changes work out.
+func (s Schedule) workingSpans(t time.Time) (rv [][2]time.Time) {
+	for _, span := range s[t.Weekday()] {
+		rv = append(rv, [2]time.Time{
+			atTimeOfDay(t, span.Start), atTimeOfDay(t, span.End)})
+	}
+	return rv
+}
@@ -0,0 +1,91 @@
+package reject
+
+import (
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// seriesHorizon is how far ahead a recurring invite's instances are looked
+// at when deciding whether to respond to the whole series.
+const seriesHorizon = 90 * 24 * time.Hour
+
+// seriesConflicts returns whether more than rule's SeriesPercent of the
+// upcoming instances of c's series conflict with blockers.
+func (r *rejecter) seriesConflicts(c candidate, loc *time.Location,
+	rule *Rule) (bool, error) {
+	now := time.Now()
+	var instances []candidate
+	err := r.srv.Events.Instances(r.calId, c.item.RecurringEventId).
+		TimeMin(now.Format(time.RFC3339)).
+		TimeMax(now.Add(seriesHorizon).Format(time.RFC3339)).
+		Pages(r.ctx, func(e *calendar.Events) error {
+			for _, item := range e.Items {
+				if item.Status == "cancelled" {
+					continue
+				}
+				start, end, err := eventSpan(item, loc)
+				if err != nil {
+					return err
+				}
+				instances = append(instances,
+					candidate{item: item, start: start, end: end})
+			}
+			return nil
+		})
+	if err != nil {
+		return false, Err.Wrap(err)
+	}
+	if len(instances) == 0 {
+		return false, nil
+	}
+
+	padding := blockerPadding + r.opts.Rules.buffer()
+	w, err := r.fetchWindow(loc, instances[0].start.Add(-padding),
+		instances[len(instances)-1].end.Add(padding))
+	if err != nil {
+		return false, err
+	}
+	conflicting := 0
+	for _, instance := range instances {
+		if r.conflict(instance, w.blockers) != nil {
+			conflicting++
+		}
+	}
+	return float64(conflicting)*100 > rule.SeriesPercent*float64(len(instances)),
+		nil
+}
+
+// seriesCandidate returns the candidate for responding to c's whole series,
+// or ok false if c should be responded to on its own. Each series is only
+// considered once per sync, and r.series remembers the outcome.
+func (r *rejecter) seriesCandidate(c candidate, loc *time.Location,
+	rule *Rule) (series candidate, ok bool, err error) {
+	if c.item.RecurringEventId == "" || rule.SeriesPercent <= 0 {
+		return c, false, nil
+	}
+	if _, seen := r.series[c.item.RecurringEventId]; seen {
+		return c, false, nil
+	}
+	declined, err := r.seriesConflicts(c, loc, rule)
+	if err != nil {
+		return c, false, err
+	}
+	r.series[c.item.RecurringEventId] = declined
+	if !declined {
+		return c, false, nil
+	}
+	parent, err := r.srv.Events.Get(r.calId, c.item.RecurringEventId).
+		Context(r.ctx).Do()
+	if err != nil {
+		return c, false, Err.Wrap(err)
+	}
+	if selfAttendee(parent) == nil {
+		r.series[c.item.RecurringEventId] = false
+		return c, false, nil
+	}
+	// the span stays that of the conflicting instance, for the reply and
+	// for withdrawing the response later
+	return candidate{item: parent, start: c.start, end: c.end,
+		accepted: c.accepted}, true, nil
+}
@@ -0,0 +1,142 @@
+package reject
+
+import (
+	"sort"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+const (
+	// suggestionHorizon is how far past the invite free slots are looked for.
+	suggestionHorizon = 14 * 24 * time.Hour
+	slotAlignment     = 15 * time.Minute
+)
+
+type timeRange struct {
+	start, end time.Time
+}
+
+// mergeRanges sorts ranges and joins the ones that overlap or touch.
+func mergeRanges(ranges []timeRange) (rv []timeRange) {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Before(ranges[j].start)
+	})
+	for _, r := range ranges {
+		if len(rv) > 0 && !r.start.After(rv[len(rv)-1].end) {
+			if r.end.After(rv[len(rv)-1].end) {
+				rv[len(rv)-1].end = r.end
+			}
+			continue
+		}
+		rv = append(rv, r)
+	}
+	return rv
+}
+
+func alignUp(t time.Time) time.Time {
+	aligned := t.Truncate(slotAlignment)
+	if aligned.Before(t) {
+		aligned = aligned.Add(slotAlignment)
+	}
+	return aligned
+}
+
+// freeSlots returns up to n start times of free slots of the given length
+// within working hours, at most one per gap between busy times. busy must be
+// merged.
+func freeSlots(busy []timeRange, hours Schedule, loc *time.Location,
+	from, until time.Time, length time.Duration, n int) (slots []time.Time) {
+	y, m, d := from.In(loc).Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, loc); day.Before(until); day = time.Date(
+		day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		for _, span := range hours.workingSpans(day) {
+			spanEnd := span[1]
+			if until.Before(spanEnd) {
+				spanEnd = until
+			}
+			t := span[0]
+			if t.Before(from) {
+				t = from
+			}
+			t = alignUp(t)
+			for !t.Add(length).After(spanEnd) {
+				i := sort.Search(len(busy), func(i int) bool {
+					return busy[i].end.After(t)
+				})
+				if i < len(busy) && busy[i].start.Before(t.Add(length)) {
+					t = alignUp(busy[i].end)
+					continue
+				}
+				slots = append(slots, t)
+				if len(slots) >= n {
+					return slots
+				}
+				if i >= len(busy) {
+					break
+				}
+				// one suggestion per gap
+				t = alignUp(busy[i].end)
+			}
+		}
+	}
+	return slots
+}
+
+// suggestSlots finds the invitee's next free slots of the invite's length,
+// using FreeBusy and the blockers already fetched.
+func (r *rejecter) suggestSlots(c candidate, loc *time.Location,
+	blockers *intervalIndex) ([]time.Time, error) {
+	if r.opts.SuggestSlots <= 0 {
+		return nil, nil
+	}
+	hours := r.workingHours()
+
+	from := c.start
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	until := from.Add(suggestionHorizon)
+
+	fb, err := r.srv.Freebusy.Query(&calendar.FreeBusyRequest{
+		TimeMin: from.Format(time.RFC3339),
+		TimeMax: until.Format(time.RFC3339),
+		Items:   []*calendar.FreeBusyRequestItem{{Id: r.calId}},
+	}).Context(r.ctx).Do()
+	if err != nil {
+		return nil, Err.Wrap(err)
+	}
+
+	var busy []timeRange
+	if cal, ok := fb.Calendars[r.calId]; ok {
+		for _, period := range cal.Busy {
+			start, err := time.Parse(time.RFC3339, period.Start)
+			if err != nil {
+				return nil, Err.Wrap(err)
+			}
+			end, err := time.Parse(time.RFC3339, period.End)
+			if err != nil {
+				return nil, Err.Wrap(err)
+			}
+			busy = append(busy, timeRange{start: start, end: end})
+		}
+	}
+	// blockers can be transparent, so FreeBusy doesn't always know about them
+	for _, blocker := range blockers.overlapping(from, until) {
+		busy = append(busy, timeRange{start: blocker.start, end: blocker.end})
+	}
+	busy = append(busy, timeRange{start: c.start, end: c.end})
+
+	return freeSlots(mergeRanges(busy), hours, loc, from, until,
+		c.end.Sub(c.start), r.opts.SuggestSlots), nil
+}
+
+// organizerLocation is the time zone the invite was scheduled in, if known.
+func organizerLocation(item *calendar.Event, loc *time.Location) *time.Location {
+	if item.Start != nil && item.Start.TimeZone != "" {
+		if orgLoc, err := time.LoadLocation(item.Start.TimeZone); err == nil {
+			return orgLoc
+		}
+	}
+	return loc
+}
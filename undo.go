@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jtolio/autoreject/reject"
+	"google.golang.org/api/calendar/v3"
+	"gopkg.in/webhelp.v1/whcompat"
+	"gopkg.in/webhelp.v1/wherr"
+	"gopkg.in/webhelp.v1/whfatal"
+)
+
+// minUndoAllowance is the shortest time an organizer stays on the temporary
+// allowlist after an undo.
+const minUndoAllowance = 24 * time.Hour
+
+type tempAllowEntry struct {
+	address string
+	expires time.Time
+}
+
+// tempAllowlistEntries is the allowlist_temp setting, one "address expiry"
+// line per entry with the expiry in RFC3339.
+type tempAllowlistEntries []tempAllowEntry
+
+// tempAllowlist parses the allowlist_temp setting, dropping expired and
+// unparseable entries.
+func tempAllowlist(val string, now time.Time) (l tempAllowlistEntries) {
+	for _, line := range strings.Split(val, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		expires, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil || !expires.After(now) {
+			continue
+		}
+		l = append(l, tempAllowEntry{address: fields[0], expires: expires})
+	}
+	return l
+}
+
+// add adds address, or extends its entry to expires.
+func (l tempAllowlistEntries) add(address string, expires time.Time) tempAllowlistEntries {
+	for i, entry := range l {
+		if entry.address == address {
+			if expires.After(entry.expires) {
+				l[i].expires = expires
+			}
+			return l
+		}
+	}
+	return append(l, tempAllowEntry{address: address, expires: expires})
+}
+
+func (l tempAllowlistEntries) active() (rv reject.AddressList) {
+	for _, entry := range l {
+		rv = append(rv, entry.address)
+	}
+	return rv
+}
+
+func (l tempAllowlistEntries) String() string {
+	lines := make([]string, 0, len(l))
+	for _, entry := range l {
+		lines = append(lines,
+			entry.address+" "+entry.expires.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// allowTemporarily puts address on the user's temporary allowlist until
+// expires.
+func (s *Site) allowTemporarily(ctx context.Context, userId, address string,
+	expires time.Time) error {
+	val, err := s.db.GetStringSetting(ctx, userId, "allowlist_temp")
+	if err != nil {
+		return err
+	}
+	l := tempAllowlist(val, time.Now()).add(strings.ToLower(address), expires)
+	return s.db.SetStringSetting(ctx, userId, "allowlist_temp", l.String())
+}
+
+// Undo takes back a decline or tentative response from the history page and
+// keeps the organizer from being declined again until the invite is over.
+func (s *Site) Undo(w http.ResponseWriter, r *http.Request) {
+	ctx := whcompat.Context(r)
+	userId := s.UserId(ctx)
+
+	status := r.FormValue("status")
+	if status != "needsAction" && status != "accepted" {
+		whfatal.Error(wherr.BadRequest.New("unknown status %q", status))
+	}
+
+	d, err := s.db.GetDecision(ctx, userId, r.FormValue("decision"))
+	if err != nil {
+		whfatal.Error(err)
+	}
+	if d.Standing() && d.Resolved {
+		whfatal.Error(wherr.BadRequest.New(
+			"decision was already withdrawn or handed to another blocker"))
+	}
+	if !d.Undoable() {
+		whfatal.Error(wherr.BadRequest.New("decision can't be undone"))
+	}
+
+	srv, err := calendar.New(s.OAuth2Client(ctx))
+	if err != nil {
+		whfatal.Error(Err.Wrap(err))
+	}
+	invite, err := srv.Events.Get(d.CalId, d.InviteId).Context(ctx).Do()
+	if err != nil {
+		whfatal.Error(Err.Wrap(err))
+	}
+	err = reject.Withdraw(ctx, srv, d.CalId, invite, status, "",
+		r.FormValue("notify") != "")
+	if err != nil {
+		whfatal.Error(err)
+	}
+
+	now := time.Now()
+	if d.Organizer != "" {
+		expires := d.End
+		if min := now.Add(minUndoAllowance); expires.Before(min) {
+			expires = min
+		}
+		err = s.allowTemporarily(ctx, userId, d.Organizer, expires)
+		if err != nil {
+			whfatal.Error(err)
+		}
+	}
+
+	err = s.db.UndoDecision(ctx, userId, d.Id)
+	if err != nil {
+		whfatal.Error(err)
+	}
+	undo := *d
+	undo.Time = now
+	undo.Action = reject.ActionRestore
+	undo.ListEntry = ""
+	err = s.db.RecordDecision(ctx, userId, &undo)
+	if err != nil {
+		whfatal.Error(err)
+	}
+
+	whfatal.Redirect("/history")
+}
@@ -0,0 +1,48 @@
+package views
+
+var _ = T.MustParse(`{{template "header" .}}
+
+<p><a href="/settings">Settings</a></p>
+
+<form method="get" action="/history">
+<p>From <input type="date" name="from" value="{{.Values.From}}">
+to <input type="date" name="to" value="{{.Values.To}}"> (UTC)
+organizer <input type="text" name="organizer" value="{{.Values.Organizer}}">
+<input type="submit" value="Filter"></p>
+</form>
+
+{{if .Values.Decisions}}
+<table>
+<tr><th>When</th><th>Calendar</th><th>Invite</th><th>Organizer</th><th>Reason</th><th>Action</th><th></th></tr>
+{{range .Values.Decisions}}
+<tr>
+<td>{{.Time.UTC.Format "2006-01-02 15:04 MST"}}</td>
+<td>{{.CalId}}</td>
+<td>{{.Summary}}{{if .Series}} <small>(whole series)</small>{{end}}<br><small>{{.Start.Format "Mon Jan 2 15:04 MST"}}</small></td>
+<td>{{.Organizer}}</td>
+<td>{{.Reason}}</td>
+<td>{{.Action}}{{if .Optional}} <small>(optional)</small>{{end}}{{if .Undone}} <small>(undone)</small>{{end}}</td>
+<td>{{if .Undoable}}
+<form method="post" action="/undo">
+<input type="hidden" name="decision" value="{{.Id}}">
+<select name="status">
+<option value="needsAction">No response</option>
+<option value="accepted">Accept</option>
+</select>
+<label><input type="checkbox" name="notify" value="1"> notify organizer</label>
+<input type="submit" value="Undo">
+</form>
+{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{if .Values.Next}}
+<p><a href="/history?from={{.Values.From}}&amp;to={{.Values.To}}&amp;organizer={{.Values.Organizer}}&amp;cursor={{.Values.Next}}">Older</a></p>
+{{end}}
+<p><small>Undoing also keeps the organizer from being declined again until
+the invite is over.</small></p>
+{{else}}
+<p>Nothing has been decided yet.</p>
+{{end}}
+
+{{template "footer" .}}`)
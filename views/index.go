@@ -2,6 +2,6 @@ package views
 
 var _ = T.MustParse(`{{template "header" .}}
 
-<p><a href="/settings">Settings</a></p>
+<p><a href="/settings">Settings</a> | <a href="/history">History</a></p>
 
 {{template "footer" .}}`)
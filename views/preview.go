@@ -0,0 +1,29 @@
+package views
+
+var _ = T.MustParse(`{{template "header" .}}
+
+<p><a href="/settings">Settings</a></p>
+
+<p>This is what autoreject would do with the pending invites on calendar
+{{.Values.CalId}} over the next four weeks right now. Nothing has been
+changed.</p>
+
+{{if .Values.Decisions}}
+<table>
+<tr><th>Invite</th><th>Organizer</th><th>When</th><th>Reason</th><th>Action</th><th>Suggested times</th></tr>
+{{range .Values.Decisions}}
+<tr>
+<td>{{.Summary}} <small>({{.InviteId}})</small></td>
+<td>{{.Organizer}}</td>
+<td>{{.Start.Format "Mon Jan 2 15:04"}} - {{.End.Format "15:04 MST"}}</td>
+<td>{{.Reason}}</td>
+<td>{{.Action}}</td>
+<td>{{range .Suggestions}}{{.Format "Mon Jan 2 15:04 MST"}}<br>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>No pending invites conflict with your blockers or policies.</p>
+{{end}}
+
+{{template "footer" .}}`)
@@ -2,6 +2,8 @@ package views
 
 var _ = T.MustParse(`{{template "header" .}}
 
+<p><a href="/history">History</a></p>
+
 <p>Google Calendar has a nice "Out of Office" event feature, where if you
 schedule an "Out of Office" event, event invites during that time will be
 automatically declined. Unfortunately, you can't make "Out of Office" events
@@ -14,7 +16,176 @@ during that time will be rejected with the provided "Autoreject reply."</p>
 
 <form method="post">
 <p>Autoreject identifier: <input type="text" name="autoreject_name" value="{{.Values.autoreject_name}}"></p>
-<p>Autoreject reply: <input type="text" name="autoreject_reply" value="{{.Values.autoreject_reply}}"></p>
+<p>Also treat these as blockers:<br>
+<label><input type="checkbox" name="native_outofoffice" value="true"{{if .Values.native_outofoffice}} checked{{end}}>
+Out of office events</label><br>
+<label><input type="checkbox" name="native_focustime" value="true"{{if .Values.native_focustime}} checked{{end}}>
+Focus time events</label><br>
+Working location days
+<select name="native_working_location">
+<option value=""{{if eq .Values.native_working_location ""}} selected{{end}}>never</option>
+<option value="notInOffice"{{if eq .Values.native_working_location "notInOffice"}} selected{{end}}>when not in an office</option>
+<option value="homeOffice"{{if eq .Values.native_working_location "homeOffice"}} selected{{end}}>when working from home</option>
+<option value="customLocation"{{if eq .Values.native_working_location "customLocation"}} selected{{end}}>when working from another location</option>
+</select></p>
+<p>Autoreject reply:<br>
+<textarea name="autoreject_reply" rows="4" cols="80">{{.Values.autoreject_reply}}</textarea></p>
+<p>The reply is a <a href="https://pkg.go.dev/text/template">Go template</a>.
+It can use <code>{{"{{"}}.OrganizerName{{"}}"}}</code>,
+<code>{{"{{"}}.OrganizerEmail{{"}}"}}</code>, <code>{{"{{"}}.Title{{"}}"}}</code>,
+<code>{{"{{"}}.Start{{"}}"}}</code>, <code>{{"{{"}}.End{{"}}"}}</code>,
+<code>{{"{{"}}.Blocker{{"}}"}}</code>, <code>{{"{{"}}.BlockerEnd{{"}}"}}</code>,
+<code>{{"{{"}}.NextFree{{"}}"}}</code> (nil if there is none) and
+<code>{{"{{"}}.Slots{{"}}"}}</code>, the suggested free times, and
+<code>{{"{{"}}.Reason{{"}}"}}</code>, which policy an invite failed. Format times with
+<code>{{"{{"}}when .BlockerEnd{{"}}"}}</code> and lists of times with
+<code>{{"{{"}}whenAll .Slots{{"}}"}}</code>.</p>
+<p>Never respond to invites from these addresses or domains:<br>
+<textarea name="allowlist" rows="3" cols="80">{{.Values.allowlist}}</textarea></p>
+<p>Always decline invites from these addresses or domains:<br>
+<textarea name="denylist" rows="3" cols="80">{{.Values.denylist}}</textarea></p>
+<p>When a blocker is removed or moved, invites it declined that no longer
+conflict go back to
+<select name="restore_status">
+<option value="needsAction"{{if eq .Values.restore_status "needsAction"}} selected{{end}}>needing a response</option>
+<option value="accepted"{{if eq .Values.restore_status "accepted"}} selected{{end}}>accepted</option>
+</select>.</p>
+<p><label><input type="checkbox" name="restore_notify" value="true"{{if .Values.restore_notify}} checked{{end}}>
+Tell the organizer the decline was withdrawn:</label><br>
+<textarea name="restore_reply" rows="2" cols="80">{{.Values.restore_reply}}</textarea></p>
+<p>When an invite you already accepted is moved into a blocker,
+<select name="moved_accepted">
+<option value="keep"{{if eq .Values.moved_accepted "keep"}} selected{{end}}>keep it accepted</option>
+<option value="respond"{{if eq .Values.moved_accepted "respond"}} selected{{end}}>respond as for a new invite</option>
+<option value="tentative"{{if eq .Values.moved_accepted "tentative"}} selected{{end}}>mark it tentative</option>
+</select>. Pending invites moved into a blocker are always handled like new
+ones.</p>
+<p>Free slots to suggest in declines: <input type="number" name="suggest_slots" min="0" max="10" value="{{.Values.suggest_slots}}"></p>
+<p>Working hours, in the calendar's time zone (for example
+<code>09:00-12:00,13:00-17:00</code>, empty for a day off):</p>
+<table>
+{{range .Values.WorkingDays}}
+<tr><td>{{.Day}}</td><td><input type="text" name="working_hours_{{.Day}}" value="{{.Ranges}}"></td></tr>
+{{end}}
+</table>
+<p><label><input type="checkbox" name="working_hours_decline" value="true"{{if .Values.working_hours_decline}} checked{{end}}>
+Decline invites that are not entirely within working hours, even without a
+blocker, with this reply:</label><br>
+<textarea name="working_hours_reply" rows="2" cols="80">{{.Values.working_hours_reply}}</textarea></p>
+<p>Decline invites that would make a day more than
+<input type="number" name="load_daily_hours" min="0" step="0.5" value="{{.Values.load_daily_hours}}">
+hours or a week more than
+<input type="number" name="load_weekly_hours" min="0" step="0.5" value="{{.Values.load_weekly_hours}}">
+hours of meetings (leave empty for no limit). Accepted and tentative
+meetings count, blockers and events without attendees don't. Reply:<br>
+<textarea name="load_reply" rows="2" cols="80">{{.Values.load_reply}}</textarea></p>
+<p>Floating blocks keep some free time somewhere in a window, one per line,
+for example <code>mon-fri 11:30-14:00 45m</code> for at least 45 free
+minutes around lunch. Invites that would take the last such slot are
+declined.<br>
+<textarea name="floating_blocks" rows="3" cols="80">{{.Values.floating_blocks}}</textarea><br>
+Reply:<br>
+<textarea name="floating_reply" rows="2" cols="80">{{.Values.floating_reply}}</textarea></p>
+<p>Decline invites that would make a run of more than
+<input type="number" name="backtoback_hours" min="0" step="0.5" value="{{.Values.backtoback_hours}}">
+hours of back-to-back meetings (leave empty for no limit). Gaps shorter than
+<input type="number" name="backtoback_gap" min="0" value="{{.Values.backtoback_gap}}">
+minutes don't break a run. Reply (<code>{{"{{"}}.Reason{{"}}"}}</code> says why):<br>
+<textarea name="backtoback_reply" rows="2" cols="80">{{.Values.backtoback_reply}}</textarea></p>
+<p>Invites sent or moved less than
+<input type="number" name="short_notice_hours" min="0" step="0.5" value="{{.Values.short_notice_hours}}">
+hours before they start (leave empty to allow any) are
+<select name="short_notice_action">
+<option value="decline"{{if eq .Values.short_notice_action "decline"}} selected{{end}}>declined</option>
+<option value="tentative"{{if eq .Values.short_notice_action "tentative"}} selected{{end}}>marked tentative</option>
+</select> with this reply:<br>
+<textarea name="short_notice_reply" rows="2" cols="80">{{.Values.short_notice_reply}}</textarea></p>
+<p>Invites sent or moved more than
+<input type="number" name="far_future_days" min="0" value="{{.Values.far_future_days}}">
+days before they start (leave empty to allow any) are
+<select name="far_future_action">
+<option value="decline"{{if eq .Values.far_future_action "decline"}} selected{{end}}>declined</option>
+<option value="tentative"{{if eq .Values.far_future_action "tentative"}} selected{{end}}>marked tentative</option>
+</select> with this reply:<br>
+<textarea name="far_future_reply" rows="2" cols="80">{{.Values.far_future_reply}}</textarea></p>
+<p><label><input type="checkbox" name="agenda_required" value="true"{{if .Values.agenda_required}} checked{{end}}>
+Invites without an agenda (an empty description)</label> are
+<select name="agenda_action">
+<option value="decline"{{if eq .Values.agenda_action "decline"}} selected{{end}}>declined</option>
+<option value="tentative"{{if eq .Values.agenda_action "tentative"}} selected{{end}}>marked tentative</option>
+</select> with this reply:<br>
+<textarea name="agenda_reply" rows="2" cols="80">{{.Values.agenda_reply}}</textarea></p>
+<p>Invites longer than
+<input type="number" name="duration_max_minutes" min="0" value="{{.Values.duration_max_minutes}}">
+minutes (leave empty to allow any) are
+<select name="duration_action">
+<option value="decline"{{if eq .Values.duration_action "decline"}} selected{{end}}>declined</option>
+<option value="tentative"{{if eq .Values.duration_action "tentative"}} selected{{end}}>marked tentative</option>
+</select> with this reply:<br>
+<textarea name="duration_reply" rows="2" cols="80">{{.Values.duration_reply}}</textarea></p>
+<p><label><input type="checkbox" name="conference_required" value="true"{{if .Values.conference_required}} checked{{end}}>
+Invites with neither a video call nor a location</label> are
+<select name="conference_action">
+<option value="decline"{{if eq .Values.conference_action "decline"}} selected{{end}}>declined</option>
+<option value="tentative"{{if eq .Values.conference_action "tentative"}} selected{{end}}>marked tentative</option>
+</select> with this reply:<br>
+<textarea name="conference_reply" rows="2" cols="80">{{.Values.conference_reply}}</textarea></p>
+<p>Invites with more than
+<input type="number" name="large_meeting_attendees" min="0" value="{{.Values.large_meeting_attendees}}">
+attendees (leave empty to allow any) are
+<select name="large_meeting_action">
+<option value="decline"{{if eq .Values.large_meeting_action "decline"}} selected{{end}}>declined</option>
+<option value="tentative"{{if eq .Values.large_meeting_action "tentative"}} selected{{end}}>marked tentative</option>
+</select> with this reply:<br>
+<textarea name="large_meeting_reply" rows="2" cols="80">{{.Values.large_meeting_reply}}</textarea></p>
+<p>Always decline invites whose title or description contains any of these,
+one per line (plain text, or a regular expression like
+<code>/quick ?sync/</code>):<br>
+<textarea name="keyword_denylist" rows="3" cols="80">{{.Values.keyword_denylist}}</textarea><br>
+Reply:<br>
+<textarea name="keyword_reply" rows="2" cols="80">{{.Values.keyword_reply}}</textarea></p>
+<p><label><input type="checkbox" name="optional_separate" value="true"{{if .Values.optional_separate}} checked{{end}}>
+Handle invites you're optional on differently:</label> instead, they are
+<select name="optional_action">
+<option value="decline"{{if eq .Values.optional_action "decline"}} selected{{end}}>declined</option>
+<option value="tentative"{{if eq .Values.optional_action "tentative"}} selected{{end}}>marked tentative</option>
+</select>,
+<select name="optional_notify">
+<option value="none"{{if eq .Values.optional_notify "none"}} selected{{end}}>without telling the organizer</option>
+<option value="all"{{if eq .Values.optional_notify "all"}} selected{{end}}>telling the organizer</option>
+</select>, with this reply (leave empty for none):<br>
+<textarea name="optional_reply" rows="2" cols="80">{{.Values.optional_reply}}</textarea><br>
+Rules can set their own <code>optional</code> response, like
+<code>"optional": {"action": "tentative", "notify": "none"}</code>.</p>
+<p>Internal domains, for rules with <code>external_only</code> (leave empty
+for your own domain):<br>
+<textarea name="internal_domains" rows="2" cols="80">{{.Values.internal_domains}}</textarea></p>
+<p>Blocker rules (optional, overrides the identifier above):<br>
+<textarea name="autoreject_rules" rows="8" cols="80">{{.Values.autoreject_rules}}</textarea></p>
+<p>Rules are JSON, for example
+<code>{"match": "any", "rules": [{"summary_contains": "(autoreject)"},
+{"color_id": "11", "transparency": "opaque"}]}</code>.
+Each rule can match on <code>summary_contains</code>,
+<code>summary_regexp</code>, <code>description_contains</code>,
+<code>description_regexp</code>, <code>color_id</code>,
+<code>transparency</code>, <code>event_type</code>,
+<code>working_location</code> and <code>calendar</code>. Only events without attendees are blockers unless
+<code>allow_attendees</code> is set. With <code>external_only</code>, a
+rule's blockers only decline invites from outside your internal domains. <code>buffer_before_minutes</code> and
+<code>buffer_after_minutes</code> extend a rule's blockers, and
+<code>min_overlap_minutes</code> or <code>min_overlap_percent</code> (of the
+invite) ignore invites that only brush against a blocker. With
+<code>series_percent</code> set, a recurring invite is responded to as a
+whole series when more than that percentage of its upcoming instances
+conflict, instead of instance by instance.</p>
+<p>Rules also say what happens to conflicting invites: <code>action</code>
+is <code>decline</code> (the default), <code>tentative</code> or
+<code>color</code> (leave the invite alone and set its color to
+<code>color</code>), and <code>notify</code> is <code>all</code> (the
+default), <code>externalOnly</code> or <code>none</code>. A single blocker
+can override its rule with lines like <code>autoreject-action: tentative</code>,
+<code>autoreject-color: 5</code> or <code>autoreject-notify: none</code> in
+its description. The command line tool reads the same format.</p>
 <p><input type="submit" value="Update"></p>
 </form>
 
@@ -23,11 +194,19 @@ during that time will be rejected with the provided "Autoreject reply."</p>
 <ul>
 {{range .Values.Calendars}}
 <li>{{if .Enabled}}
+<form method="get" action="/preview">
+<input type="hidden" name="cal" value="{{.Id}}">
+<input type="submit" value="Preview">
+</form>
 <form method="post" action="/unregister">
 <input type="hidden" name="cal" value="{{.Id}}">
 <input type="submit" value="Unregister calendar {{if (ne .SummaryOverride "")}}{{.SummaryOverride}}{{else}}{{.Summary}}{{end}}">
 </form>
 {{else}}
+<form method="get" action="/preview">
+<input type="hidden" name="cal" value="{{.Id}}">
+<input type="submit" value="Preview">
+</form>
 <form method="post" action="/register">
 <input type="hidden" name="cal" value="{{.Id}}">
 <input type="submit" value="Register calendar {{if (ne .SummaryOverride "")}}{{.SummaryOverride}}{{else}}{{.Summary}}{{end}}">